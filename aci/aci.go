@@ -0,0 +1,289 @@
+package aci
+
+import (
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+	"gopkg.in/yaml.v2"
+)
+
+var invalidNameChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// sanitizeName converts a string into a valid container group, container, or volume name
+func sanitizeName(name string) string {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(name) > 63 {
+		name = strings.Trim(name[:63], "-")
+	}
+	return name
+}
+
+// EnvironmentVariable is a type for storing an ACI environment variable
+type EnvironmentVariable struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// EnvironmentVariables is a composite type for slices of EnvironmentVariable
+type EnvironmentVariables []EnvironmentVariable
+
+func (env EnvironmentVariables) Len() int      { return len(env) }
+func (env EnvironmentVariables) Swap(i, j int) { env[i], env[j] = env[j], env[i] }
+func (env EnvironmentVariables) Less(i, j int) bool {
+	return strings.Compare(env[i].Name, env[j].Name) < 0
+}
+
+// Port is a type for storing an ACI container or group port
+type Port struct {
+	Port     int    `yaml:"port"`
+	Protocol string `yaml:"protocol"`
+}
+
+// ResourceRequests is a type for storing an ACI container's resource requests
+type ResourceRequests struct {
+	CPU        float64 `yaml:"cpu"`
+	MemoryInGB float64 `yaml:"memoryInGB"`
+}
+
+// Resources is a type for storing an ACI container's resources
+type Resources struct {
+	Requests ResourceRequests `yaml:"requests"`
+}
+
+// VolumeMount is a type for storing an ACI container's volume mount
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+// ContainerProperties is a type for storing an ACI container's properties
+type ContainerProperties struct {
+	Image                string               `yaml:"image"`
+	Command              []string             `yaml:"command,omitempty"`
+	Ports                []Port               `yaml:"ports,omitempty"`
+	EnvironmentVariables EnvironmentVariables `yaml:"environmentVariables,omitempty"`
+	Resources            Resources            `yaml:"resources"`
+	VolumeMounts         []VolumeMount        `yaml:"volumeMounts,omitempty"`
+}
+
+// Container is a type for storing an ACI container
+type Container struct {
+	Name       string              `yaml:"name"`
+	Properties ContainerProperties `yaml:"properties"`
+}
+
+// IPAddress is a type for storing a container group's public IP address
+type IPAddress struct {
+	Type  string `yaml:"type"`
+	Ports []Port `yaml:"ports"`
+}
+
+// DNSConfig is a type for storing a container group's DNS settings
+type DNSConfig struct {
+	NameServers   []string `yaml:"nameServers"`
+	SearchDomains string   `yaml:"searchDomains,omitempty"`
+}
+
+// EmptyDirVolume is a type for storing an ACI emptyDir volume
+type EmptyDirVolume struct{}
+
+// Volume is a type for storing a container group volume
+type Volume struct {
+	Name     string          `yaml:"name"`
+	EmptyDir *EmptyDirVolume `yaml:"emptyDir"`
+}
+
+// GroupProperties is a type for storing a container group's properties
+type GroupProperties struct {
+	Containers    []Container `yaml:"containers"`
+	OSType        string      `yaml:"osType"`
+	RestartPolicy string      `yaml:"restartPolicy"`
+	IPAddress     *IPAddress  `yaml:"ipAddress,omitempty"`
+	DNSConfig     *DNSConfig  `yaml:"dnsConfig,omitempty"`
+	Volumes       []Volume    `yaml:"volumes,omitempty"`
+}
+
+// ContainerGroup represents an Azure Container Instances container group.
+// It implements OutputFormat
+type ContainerGroup struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Name       string            `yaml:"name"`
+	Type       string            `yaml:"type"`
+	Tags       map[string]string `yaml:"tags,omitempty"`
+	Properties GroupProperties   `yaml:"properties"`
+}
+
+// roundUp rounds a value up to the given increment
+func roundUp(value, increment float64) float64 {
+	return math.Ceil(value/increment-1e-9) * increment
+}
+
+func (c *Container) emitResources(cpu, mem int) {
+	// ACI requires resource requests, so docker's defaults of a full CPU and
+	// ACI's default of 1.5GB of memory are used when unset
+	cores := 1.0
+	if cpu > 0 {
+		cores = math.Max(roundUp(float64(cpu)/1024, 0.01), 0.01)
+	}
+	memory := 1.5
+	if mem > 0 {
+		// ACI allocates memory in increments of 0.1GB
+		memory = math.Max(roundUp(float64(mem)/(1<<30), 0.1), 0.1)
+	}
+	c.Properties.Resources.Requests = ResourceRequests{
+		CPU:        math.Floor(cores*100+0.5) / 100,
+		MemoryInGB: math.Floor(memory*10+0.5) / 10,
+	}
+}
+
+func (c *Container) emitEnvironment(env map[string]string) {
+	if len(env) > 0 {
+		envs := EnvironmentVariables{}
+		for n, v := range env {
+			envs = append(envs, EnvironmentVariable{Name: n, Value: v})
+		}
+		sort.Sort(envs)
+		c.Properties.EnvironmentVariables = envs
+	}
+}
+
+// emitCommand sets the container's command. ACI's command replaces the image's
+// entrypoint, so the entrypoint and command are combined
+func (c *Container) emitCommand(container transform.Container) {
+	if len(container.Entrypoint) == 0 && len(container.Command) > 0 {
+		log.Printf("ACI replaces the image entrypoint with the command for container %s", container.Name)
+	}
+	command := append(append([]string{}, container.Entrypoint...), container.CommandArgs()...)
+	if len(command) > 0 {
+		c.Properties.Command = command
+	}
+}
+
+// emitPorts adds a container's ports, returning the ports to publish on the
+// group's IP address
+func (c *Container) emitPorts(container transform.Container) []Port {
+	public := []Port{}
+	if container.PortMappings == nil {
+		return public
+	}
+	seen := map[Port]bool{}
+	for _, pm := range *container.PortMappings {
+		if pm.ContainerPort == 0 {
+			continue
+		}
+		port := Port{Port: pm.ContainerPort, Protocol: strings.ToUpper(pm.Protocol)}
+		if len(port.Protocol) == 0 {
+			port.Protocol = "TCP"
+		}
+		if seen[port] {
+			continue
+		}
+		seen[port] = true
+		c.Properties.Ports = append(c.Properties.Ports, port)
+		if pm.HostPort > 0 {
+			if pm.HostPort != pm.ContainerPort {
+				log.Printf("ACI cannot remap ports, publishing container port %d instead of %d for container %s", pm.ContainerPort, pm.HostPort, container.Name)
+			}
+			public = append(public, port)
+		}
+	}
+	return public
+}
+
+// emitVolumes mounts a container's named and anonymous volumes as emptyDir
+// volumes, returning the volume names. ACI cannot mount host paths
+func (c *Container) emitVolumes(vols *transform.IntermediateVolumes) []string {
+	names := []string{}
+	if vols == nil {
+		return names
+	}
+	for _, volume := range *vols {
+		name := volume.NamedVolume()
+		if len(volume.Host) > 0 && len(name) == 0 {
+			log.Printf("ACI cannot mount host paths, ignoring volume %s for container %s", volume.Host, c.Name)
+			continue
+		}
+		if len(name) == 0 {
+			name = c.Name + "-" + volume.Container
+		}
+		name = sanitizeName(name)
+		names = append(names, name)
+		c.Properties.VolumeMounts = append(c.Properties.VolumeMounts, VolumeMount{
+			Name:      name,
+			MountPath: volume.Container,
+			ReadOnly:  volume.ReadOnly,
+		})
+	}
+	return names
+}
+
+// groupName returns the container group's name, falling back to its first
+// container's name
+func groupName(input *transform.PodData) string {
+	if name := sanitizeName(input.Name); len(name) > 0 {
+		return name
+	}
+	for _, container := range *input.Containers {
+		return sanitizeName(container.Name)
+	}
+	return ""
+}
+
+// EmitContainers satisfies OutputFormat so ACI container groups can be emitted
+func (cg ContainerGroup) EmitContainers(input *transform.PodData) ([]byte, error) {
+	output := &ContainerGroup{
+		APIVersion: "2021-10-01",
+		Name:       groupName(input),
+		Type:       "Microsoft.ContainerInstance/containerGroups",
+		Tags:       input.GlobalLabels,
+		Properties: GroupProperties{
+			OSType:        "Linux",
+			RestartPolicy: "Always",
+		},
+	}
+
+	public := []Port{}
+	volumes := map[string]bool{}
+	dns := &DNSConfig{}
+	for _, container := range *input.Containers {
+		aciContainer := Container{Name: sanitizeName(container.Name)}
+		aciContainer.Properties.Image = container.Image
+		aciContainer.emitCommand(container)
+		public = append(public, aciContainer.emitPorts(container)...)
+		aciContainer.emitEnvironment(container.Environment)
+		aciContainer.emitResources(container.CPU, container.Memory)
+		for _, name := range aciContainer.emitVolumes(container.Volumes) {
+			volumes[name] = true
+		}
+		if container.Privileged {
+			log.Printf("ACI does not run privileged containers, ignoring privileged for container %s", container.Name)
+		}
+		dns.NameServers = append(dns.NameServers, container.DNS...)
+		if len(dns.SearchDomains) == 0 {
+			dns.SearchDomains = strings.Join(container.Domain, " ")
+		}
+		output.Properties.Containers = append(output.Properties.Containers, aciContainer)
+	}
+
+	if len(public) > 0 {
+		output.Properties.IPAddress = &IPAddress{Type: "Public", Ports: public}
+	}
+	if len(dns.NameServers) > 0 {
+		output.Properties.DNSConfig = dns
+	}
+	names := []string{}
+	for name := range volumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		output.Properties.Volumes = append(output.Properties.Volumes, Volume{Name: name, EmptyDir: &EmptyDirVolume{}})
+	}
+
+	return yaml.Marshal(output)
+}
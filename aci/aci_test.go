@@ -0,0 +1,61 @@
+package aci
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestEmitContainers(t *testing.T) {
+	cf := compose.DockerCompose{}
+
+	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := cf.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+	bp.Name = "DR Web"
+
+	got, err := ContainerGroup{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/container-group.yaml")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitResources(t *testing.T) {
+	cases := []struct {
+		cpu, mem   int
+		cores, gib float64
+	}{
+		{0, 0, 1, 1.5},
+		{1024, 1 << 30, 1, 1},
+		{2048, 64 << 20, 2, 0.1},
+		{100, 300 << 20, 0.1, 0.3},
+		{1, 1, 0.01, 0.1},
+	}
+	for _, c := range cases {
+		container := Container{}
+		container.emitResources(c.cpu, c.mem)
+		assert.Equal(t, ResourceRequests{CPU: c.cores, MemoryInGB: c.gib}, container.Properties.Resources.Requests)
+	}
+}
@@ -0,0 +1,2 @@
+// Package aci is for emitting Azure Container Instances container groups
+package aci
@@ -0,0 +1,209 @@
+package beanstalk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/container-tx/ecs"
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// appDir is where Beanstalk deploys the application source bundle, which
+// relative host paths are resolved against
+const appDir = "/var/app/current"
+
+// logVolumePrefix prefixes the volumes Beanstalk creates for each container's logs
+const logVolumePrefix = "awseb-logs-"
+
+// Authentication is a type for storing the S3 location of a registry
+// authentication file
+type Authentication struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+}
+
+func (d Dockerrun) ingestAuthentication() string {
+	if d.Authentication != nil {
+		return "s3://" + d.Authentication.Bucket + "/" + d.Authentication.Key
+	}
+	return ""
+}
+
+func (d *Dockerrun) emitAuthentication(location string) {
+	if len(location) == 0 {
+		return
+	}
+	if !strings.HasPrefix(location, "s3://") {
+		log.Printf("Beanstalk only reads registry authentication from S3, ignoring %s", location)
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(location, "s3://"), "/", 2)
+	d.Authentication = &Authentication{Bucket: parts[0]}
+	if len(parts) > 1 {
+		d.Authentication.Key = parts[1]
+	}
+}
+
+// ingestVolumes resolves source bundle paths to relative host paths
+func ingestVolumes(vols *ecs.Volumes) map[string]string {
+	volumes := ecs.VolumesToMap(vols)
+	for name, sourcePath := range volumes {
+		if sourcePath == appDir {
+			volumes[name] = "."
+		} else if strings.HasPrefix(sourcePath, appDir+"/") {
+			volumes[name] = "./" + strings.TrimPrefix(sourcePath, appDir+"/")
+		}
+	}
+	return volumes
+}
+
+// emitVolumes resolves relative host paths against the source bundle
+func emitVolumes(vols *transform.IntermediateVolumes) *transform.IntermediateVolumes {
+	if vols == nil {
+		return nil
+	}
+	response := transform.IntermediateVolumes{}
+	for _, volume := range *vols {
+		if strings.HasPrefix(volume.Host, ".") {
+			volume.Host = path.Join(appDir, volume.Host)
+		}
+		response = append(response, volume)
+	}
+	return &response
+}
+
+// renameBundleVolumes names the volumes in the source bundle after their path
+// relative to it, rather than their full host path
+func renameBundleVolumes(containers ecs.Containers, volumes map[string]string) map[string]string {
+	names := map[string]string{}
+	response := map[string]string{}
+	for name, sourcePath := range volumes {
+		if strings.HasPrefix(sourcePath, appDir+"/") {
+			names[name] = strings.Replace(strings.TrimPrefix(sourcePath, appDir+"/"), "/", "-", -1)
+			response[names[name]] = sourcePath
+			continue
+		}
+		response[name] = sourcePath
+	}
+	for _, container := range containers {
+		if container.Volumes == nil {
+			continue
+		}
+		for i, mp := range *container.Volumes {
+			if name, ok := names[mp.SourceVolume]; ok {
+				(*container.Volumes)[i].SourceVolume = name
+			}
+		}
+	}
+	return response
+}
+
+// withoutLogVolumes removes the mount points of Beanstalk's log volumes, which
+// Beanstalk creates itself
+func withoutLogVolumes(container ecs.Container) ecs.Container {
+	if container.Volumes == nil {
+		return container
+	}
+	mountPoints := ecs.MountPoints{}
+	for _, mp := range *container.Volumes {
+		if strings.HasPrefix(mp.SourceVolume, logVolumePrefix) {
+			log.Printf("Ignoring Beanstalk log volume %s for container %s", mp.SourceVolume, container.Name)
+			continue
+		}
+		mountPoints = append(mountPoints, mp)
+	}
+	container.Volumes = &mountPoints
+	if len(mountPoints) == 0 {
+		container.Volumes = nil
+	}
+	return container
+}
+
+// DockerrunVersion is a Dockerrun file's version, which Beanstalk accepts as a
+// number or as a string
+type DockerrunVersion int
+
+// UnmarshalJSON implements a custom unmarshal to accommodate string versions
+func (v *DockerrunVersion) UnmarshalJSON(data []byte) error {
+	var version string
+	if err := json.Unmarshal(data, &version); err != nil {
+		return json.Unmarshal(data, (*int)(v))
+	}
+	number, err := strconv.Atoi(version)
+	if err != nil {
+		return fmt.Errorf("invalid AWSEBDockerrunVersion %s", version)
+	}
+	*v = DockerrunVersion(number)
+	return nil
+}
+
+// Dockerrun represents a Beanstalk multicontainer Dockerrun.aws.json file,
+// whose container definitions match ECS's. It implements InputFormat and OutputFormat
+type Dockerrun struct {
+	AWSEBDockerrunVersion DockerrunVersion `json:"AWSEBDockerrunVersion"`
+	Authentication        *Authentication  `json:"authentication,omitempty"`
+	Volumes               *ecs.Volumes     `json:"volumes,omitempty"`
+	ContainerDefinitions  *ecs.Containers  `json:"containerDefinitions"`
+}
+
+// IngestContainers satisfies InputFormat so Beanstalk Dockerrun files can be ingested
+func (d Dockerrun) IngestContainers(input io.ReadCloser) (*transform.PodData, error) {
+
+	body, err := ioutil.ReadAll(input)
+	defer input.Close()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	err = json.Unmarshal(body, &d)
+	if err != nil {
+		return nil, err
+	}
+	if d.AWSEBDockerrunVersion != 2 {
+		return nil, fmt.Errorf("unsupported AWSEBDockerrunVersion %d, only multicontainer version 2 is supported", d.AWSEBDockerrunVersion)
+	}
+
+	outputPod := transform.PodData{RegistryAuth: d.ingestAuthentication()}
+	containers := transform.Containers{}
+
+	volumes := ingestVolumes(d.Volumes)
+	if d.ContainerDefinitions != nil {
+		for _, container := range *d.ContainerDefinitions {
+			containers = append(containers, ecs.IngestContainer(withoutLogVolumes(container), volumes))
+		}
+	}
+	sort.Sort(containers)
+	outputPod.Containers = &containers
+
+	return &outputPod, nil
+}
+
+// EmitContainers satisfies OutputFormat so Beanstalk Dockerrun files can be emitted
+func (d Dockerrun) EmitContainers(input *transform.PodData) ([]byte, error) {
+	output := &Dockerrun{AWSEBDockerrunVersion: 2}
+	output.emitAuthentication(input.RegistryAuth)
+
+	containers := ecs.Containers{}
+	volumes := map[string]string{}
+	for _, container := range *input.Containers {
+		if len(container.Secrets) > 0 {
+			log.Printf("Beanstalk does not support secrets, dropping secrets for container %s", container.Name)
+		}
+		container.Volumes = emitVolumes(container.Volumes)
+		containers = append(containers, ecs.EmitContainer(container, volumes))
+	}
+	if len(volumes) > 0 {
+		output.Volumes = ecs.MapToVolumes(renameBundleVolumes(containers, volumes))
+	}
+
+	sort.Sort(containers)
+	output.ContainerDefinitions = &containers
+
+	return json.MarshalIndent(output, "", "    ")
+}
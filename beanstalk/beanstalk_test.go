@@ -0,0 +1,81 @@
+package beanstalk
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestIngestContainers(t *testing.T) {
+	f, err := os.Open("./test_fixtures/Dockerrun.aws.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := Dockerrun{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, "s3://my-bucket/mydockercfg", bp.RegistryAuth)
+	assert.Len(t, *bp.Containers, 2)
+
+	proxy := (*bp.Containers)[0]
+	assert.Equal(t, "nginx-proxy", proxy.Name)
+	assert.Equal(t, &transform.IntermediateVolumes{
+		{Host: "./php-app", Container: "/var/www/html", ReadOnly: true},
+		{Host: "./proxy/conf.d", Container: "/etc/nginx/conf.d", ReadOnly: true},
+	}, proxy.Volumes)
+}
+
+func TestEmitContainers(t *testing.T) {
+	f, err := os.Open("./test_fixtures/Dockerrun.aws.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := Dockerrun{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := Dockerrun{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/emitted.json")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestIngestVersion1(t *testing.T) {
+	body := `{"AWSEBDockerrunVersion": 1, "Image": {"Name": "nginx"}}`
+
+	_, err := Dockerrun{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if assert.Error(t, err) {
+		assert.Equal(t, "unsupported AWSEBDockerrunVersion 1, only multicontainer version 2 is supported", err.Error())
+	}
+}
+
+func TestIngestStringVersion(t *testing.T) {
+	body := `{"AWSEBDockerrunVersion": "2", "containerDefinitions": [{"name": "web", "image": "nginx", "memory": 128}]}`
+
+	bp, err := Dockerrun{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Fatalf("Failed to ingest containers: %s", err)
+	}
+	assert.Equal(t, "nginx", (*bp.Containers)[0].Image)
+}
@@ -0,0 +1,2 @@
+// Package beanstalk is for ingesting and emitting Elastic Beanstalk multicontainer Dockerrun files
+package beanstalk
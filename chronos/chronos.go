@@ -0,0 +1,238 @@
+package chronos
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/micahhausler/container-tx/marathon"
+	"github.com/micahhausler/container-tx/transform"
+)
+
+func (j Job) ingestEnvironment() map[string]string {
+	if len(j.Environment) > 0 {
+		env := map[string]string{}
+		for _, envVar := range j.Environment {
+			env[envVar.Name] = envVar.Value
+		}
+		return env
+	}
+	return nil
+}
+
+func (j *Job) emitEnvironment(env map[string]string) {
+	if len(env) > 0 {
+		envs := Environments{}
+		for n, v := range env {
+			envs = append(envs, Environment{Name: n, Value: v})
+		}
+		sort.Sort(envs)
+		j.Environment = envs
+	}
+}
+
+// Environment is a type for storing Chronos environment variables
+type Environment struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Environments is a composite type for slices of Environment
+type Environments []Environment
+
+func (env Environments) Len() int      { return len(env) }
+func (env Environments) Swap(i, j int) { env[i], env[j] = env[j], env[i] }
+func (env Environments) Less(i, j int) bool {
+	return strings.Compare(env[i].Name, env[j].Name) < 0
+}
+
+func (j Job) ingestFetch() []*transform.Fetch {
+	response := []*transform.Fetch{}
+	for _, uri := range j.URIs {
+		response = append(response, &transform.Fetch{URI: uri})
+	}
+	for _, fetch := range j.Fetch {
+		response = append(response, &transform.Fetch{URI: fetch.URI})
+	}
+	if len(response) > 0 {
+		return response
+	}
+	return nil
+}
+
+func (j *Job) emitFetch(in []*transform.Fetch) {
+	for _, fetch := range in {
+		if fetch != nil {
+			j.URIs = append(j.URIs, fetch.URI)
+		}
+	}
+}
+
+func (c Container) ingestVolumes() *transform.IntermediateVolumes {
+	if len(c.Volumes) > 0 {
+		response := transform.IntermediateVolumes{}
+		for _, vol := range c.Volumes {
+			response = append(response, transform.IntermediateVolume{
+				Host:      vol.HostPath,
+				Container: vol.ContainerPath,
+				ReadOnly:  strings.ToUpper(vol.Mode) == "RO",
+			})
+		}
+		return &response
+	}
+	return nil
+}
+
+func (c *Container) emitVolumes(vols *transform.IntermediateVolumes) {
+	if vols == nil {
+		return
+	}
+	for _, volume := range *vols {
+		if len(volume.Host) == 0 {
+			c.Parameters = append(c.Parameters, marathon.Parameter{Key: "volume", Value: volume.Container})
+			continue
+		}
+		mode := "RW"
+		if volume.ReadOnly {
+			mode = "RO"
+		}
+		c.Volumes = append(c.Volumes, marathon.Volume{
+			ContainerPath: volume.Container,
+			HostPath:      volume.Host,
+			Mode:          mode,
+		})
+	}
+}
+
+// Container is a type for storing a Chronos job's docker container. Chronos
+// runs on Mesos, so its volumes and parameters match Marathon's
+type Container struct {
+	Type           string              `json:"type"`
+	Image          string              `json:"image"`
+	Network        string              `json:"network,omitempty"`
+	Volumes        []marathon.Volume   `json:"volumes,omitempty"`
+	ForcePullImage bool                `json:"forcePullImage,omitempty"`
+	Parameters     marathon.Parameters `json:"parameters,omitempty"`
+}
+
+// Job represents a Chronos job. It implements InputFormat and OutputFormat,
+// and pods with more than one container are emitted as a list of jobs
+type Job struct {
+	Name        string           `json:"name"`
+	Command     string           `json:"command"`
+	Shell       bool             `json:"shell"`
+	Schedule    string           `json:"schedule,omitempty"`
+	CPUs        float64          `json:"cpus,omitempty"`
+	Mem         float64          `json:"mem,omitempty"`
+	Container   *Container       `json:"container,omitempty"`
+	Environment Environments     `json:"environmentVariables,omitempty"`
+	URIs        []string         `json:"uris,omitempty"`
+	Fetch       []marathon.Fetch `json:"fetch,omitempty"`
+}
+
+// ingestJob converts a Chronos job into an intermediate container
+func ingestJob(job Job) transform.Container {
+	ir := transform.Container{}
+	if len(job.Command) > 0 {
+		// Chronos runs its command with a shell
+		ir.Command = []string{job.Command}
+		ir.ShellForm = true
+	}
+	// Chronos measures CPUs in cores and memory in MB
+	ir.CPU = int(job.CPUs * 1024)
+	ir.Environment = job.ingestEnvironment()
+	ir.Fetch = job.ingestFetch()
+	ir.Memory = int(job.Mem * (1 << 20))
+	ir.Name = job.Name
+	ir.Schedule = job.Schedule
+	if job.Container != nil {
+		ir.Image = job.Container.Image
+		if strings.ToUpper(job.Container.Network) == "HOST" {
+			ir.NetworkMode = "host"
+		}
+		if job.Container.ForcePullImage {
+			ir.PullImagePolicy = "always"
+		}
+		ir.Volumes = job.Container.ingestVolumes()
+		job.Container.Parameters.Ingest(&ir)
+	}
+	return ir
+}
+
+// emitJob converts an intermediate container into a Chronos job
+func emitJob(input *transform.PodData, container transform.Container) Job {
+	command := transform.ShellJoin(container.RunArgs())
+	if container.ShellForm && len(container.Entrypoint) < 2 {
+		command = container.CommandLine()
+	}
+	job := Job{
+		Name:     container.Name,
+		Command:  command,
+		Shell:    true,
+		Schedule: container.Schedule,
+		CPUs:     float64(container.CPU) / 1024,
+		Mem:      float64(container.Memory) / (1 << 20),
+		Container: &Container{
+			Type:           "DOCKER",
+			Image:          container.Image,
+			ForcePullImage: container.PullImagePolicy == "always",
+			Parameters:     marathon.EmitParameters(container),
+		},
+	}
+	if container.NetworkMode == "host" || input.HostNetwork {
+		job.Container.Network = "HOST"
+	}
+	job.Container.emitVolumes(container.Volumes)
+	job.emitEnvironment(container.Environment)
+	job.emitFetch(container.Fetch)
+	return job
+}
+
+// IngestContainers satisfies InputFormat so Chronos jobs can be ingested
+func (j Job) IngestContainers(input io.ReadCloser) (*transform.PodData, error) {
+
+	body, err := ioutil.ReadAll(input)
+	defer input.Close()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	jobs := []Job{}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		err = json.Unmarshal(body, &jobs)
+	} else {
+		err = json.Unmarshal(body, &j)
+		jobs = append(jobs, j)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	outputPod := transform.PodData{}
+	containers := transform.Containers{}
+	for _, job := range jobs {
+		if len(outputPod.Name) == 0 {
+			outputPod.Name = job.Name
+		}
+		containers = append(containers, ingestJob(job))
+	}
+	sort.Sort(containers)
+	outputPod.Containers = &containers
+
+	return &outputPod, nil
+}
+
+// EmitContainers satisfies OutputFormat so Chronos jobs can be emitted
+func (j Job) EmitContainers(input *transform.PodData) ([]byte, error) {
+	jobs := []Job{}
+	for _, container := range *input.Containers {
+		jobs = append(jobs, emitJob(input, container))
+	}
+	if len(jobs) == 1 {
+		return json.MarshalIndent(jobs[0], "", "    ")
+	}
+	return json.MarshalIndent(jobs, "", "    ")
+}
@@ -0,0 +1,112 @@
+package chronos
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestIngestContainers(t *testing.T) {
+	f, err := os.Open("./test_fixtures/job.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := Job{}.IngestContainers(f)
+	if err != nil {
+		t.Fatalf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, "backup", bp.Name)
+	container := (*bp.Containers)[0]
+	assert.Equal(t, "R/2016-09-25T17:22:00Z/PT24H", container.Schedule)
+	assert.Equal(t, "postgres:9.6", container.Image)
+	assert.Equal(t, 512, container.CPU)
+	assert.Equal(t, 256<<20, container.Memory)
+	assert.Equal(t, "host", container.NetworkMode)
+	assert.Equal(t, "postgres", container.User)
+	assert.Equal(t, "postgres", container.Environment["PGPASSWORD"])
+}
+
+func TestRoundTrip(t *testing.T) {
+	f, err := os.Open("./test_fixtures/job.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := Job{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := Job{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/job.json")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitContainers(t *testing.T) {
+	cf := compose.DockerCompose{}
+
+	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := cf.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := Job{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	bp, err = Job{}.IngestContainers(ioutil.NopCloser(bytes.NewReader(got)))
+	if err != nil {
+		t.Errorf("Failed to ingest emitted jobs: %s", err)
+	}
+	assert.Len(t, *bp.Containers, 3)
+}
+
+func TestJobSchedules(t *testing.T) {
+	body := `[
+    {"name": "backup", "command": "pg_dump", "shell": true, "schedule": "R/2016-09-25T17:22:00Z/PT24H"},
+    {"name": "vacuum", "command": "vacuumdb", "shell": true, "schedule": "R/2016-09-25T03:00:00Z/P7D"}
+]`
+
+	bp, err := Job{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Fatalf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := Job{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	jobs := []Job{}
+	if err := json.Unmarshal(got, &jobs); err != nil {
+		t.Fatalf("Failed to read emitted jobs: %s", err)
+	}
+	assert.Equal(t, "R/2016-09-25T17:22:00Z/PT24H", jobs[0].Schedule)
+	assert.Equal(t, "R/2016-09-25T03:00:00Z/P7D", jobs[1].Schedule)
+}
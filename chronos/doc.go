@@ -0,0 +1,2 @@
+// Package chronos is for ingesting and emitting Chronos job definitions
+package chronos
@@ -1,8 +1,10 @@
 package compose
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"sort"
 	"strconv"
 	"strings"
@@ -28,6 +30,15 @@ func (b *Build) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
+// MarshalYAML emits a build context in compose's string format when it only
+// has a context, otherwise in its struct format
+func (b Build) MarshalYAML() (interface{}, error) {
+	if len(b.Ctx.Dockerfile) == 0 && len(b.Ctx.Args) == 0 {
+		return b.Ctx.Context, nil
+	}
+	return b.Ctx, nil
+}
+
 // Build is a struct for Compose build info
 type Build struct {
 	Ctx BuildContext
@@ -104,11 +115,60 @@ func parseComposePortMapping(line string) *transform.PortMapping {
 	return pm
 }
 
+// longPort is the long syntax of a compose port mapping
+type longPort struct {
+	Target    int    `yaml:"target"`
+	Published int    `yaml:"published,omitempty"`
+	Protocol  string `yaml:"protocol,omitempty"`
+	Mode      string `yaml:"mode,omitempty"`
+}
+
+// Port is a compose port mapping, in either the short "host:container" syntax
+// or the long syntax of version 3 files
+type Port struct {
+	Short string
+	Long  longPort
+}
+
+// UnmarshalYAML implements a custom unmarshal to accommodate both of compose's
+// short and long port syntaxes
+func (p *Port) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	err := unmarshal(&p.Short)
+	if err != nil {
+		p.Short = ""
+		return unmarshal(&p.Long)
+	}
+	return nil
+}
+
+// MarshalYAML emits a port in the syntax it was created with
+func (p Port) MarshalYAML() (interface{}, error) {
+	if len(p.Short) > 0 {
+		return p.Short, nil
+	}
+	return p.Long, nil
+}
+
+func (p Port) ingest() *transform.PortMapping {
+	if len(p.Short) > 0 {
+		return parseComposePortMapping(p.Short)
+	}
+	protocol := p.Long.Protocol
+	if len(protocol) == 0 {
+		protocol = "tcp"
+	}
+	return &transform.PortMapping{
+		HostPort:      p.Long.Published,
+		ContainerPort: p.Long.Target,
+		Protocol:      protocol,
+	}
+}
+
 func (c Container) ingestPortMappings() *transform.PortMappings {
 	if len(c.PortMappings) > 0 {
 		response := transform.PortMappings{}
 		for _, pm := range c.PortMappings {
-			response = append(response, *parseComposePortMapping(pm))
+			response = append(response, *pm.ingest())
 		}
 		return &response
 	}
@@ -119,7 +179,7 @@ func (c *Container) emitPortMappings(mappings *transform.PortMappings) {
 	if mappings == nil {
 		return
 	}
-	output := []string{}
+	output := []Port{}
 	for _, mapping := range *mappings {
 		portStr := []string{}
 		if mapping.HostPort > 0 {
@@ -138,7 +198,7 @@ func (c *Container) emitPortMappings(mappings *transform.PortMappings) {
 			if strings.Compare(mapping.Protocol, "udp") == 0 {
 				portData = portData + "/udp"
 			}
-			output = append(output, portData)
+			output = append(output, Port{Short: portData})
 		}
 	}
 	if len(output) > 0 {
@@ -148,8 +208,8 @@ func (c *Container) emitPortMappings(mappings *transform.PortMappings) {
 
 // Logging is a logging type for compose
 type Logging struct {
-	Driver  string
-	Options map[string]string
+	Driver  string            `yaml:"driver,omitempty"`
+	Options map[string]string `yaml:"options,omitempty"`
 }
 
 func (c Container) ingestLogging() *transform.Logging {
@@ -171,6 +231,364 @@ func (c *Container) emitLogging(l *transform.Logging) {
 	}
 }
 
+// Command is a command or entrypoint, which compose allows as a list or as a
+// string split into words like a shell would
+type Command []string
+
+// UnmarshalYAML implements a custom unmarshal to accommodate both of compose's
+// command forms
+func (cmd *Command) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var line string
+	if err := unmarshal(&line); err == nil {
+		words, err := transform.ShellSplit(line)
+		if err != nil {
+			return fmt.Errorf("invalid command %s: %s", line, err)
+		}
+		*cmd = words
+		return nil
+	}
+	var args []string
+	if err := unmarshal(&args); err != nil {
+		return err
+	}
+	*cmd = args
+	return nil
+}
+
+// StringList is a list of strings, which compose also allows as a single string
+type StringList []string
+
+// UnmarshalYAML implements a custom unmarshal to accommodate a single string
+func (sl *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*sl = StringList{value}
+		return nil
+	}
+	var values []string
+	if err := unmarshal(&values); err != nil {
+		return err
+	}
+	*sl = values
+	return nil
+}
+
+func (c Container) ingestDevices() []transform.Device {
+	response := []transform.Device{}
+	for _, device := range c.Devices {
+		response = append(response, transform.ParseDevice(device))
+	}
+	if len(response) == 0 {
+		return nil
+	}
+	return response
+}
+
+func (c *Container) emitDevices(devices []transform.Device) {
+	for _, device := range devices {
+		c.Devices = append(c.Devices, device.String())
+	}
+}
+
+func (c Container) ingestTmpfs(name string) []transform.Tmpfs {
+	response := []transform.Tmpfs{}
+	for _, value := range c.Tmpfs {
+		tmpfs, err := transform.ParseTmpfs(value)
+		if err != nil {
+			log.Printf("Ignoring tmpfs size for container %s: %s", name, err)
+		}
+		response = append(response, tmpfs)
+	}
+	if len(response) == 0 {
+		return nil
+	}
+	return response
+}
+
+func (c *Container) emitTmpfs(mounts []transform.Tmpfs) {
+	for _, tmpfs := range mounts {
+		c.Tmpfs = append(c.Tmpfs, tmpfs.String())
+	}
+}
+
+func (c Container) ingestShmSize(name string) int {
+	if len(c.ShmSize) == 0 {
+		return 0
+	}
+	size, err := transform.ParseBytes(c.ShmSize)
+	if err != nil {
+		log.Printf("Ignoring shm_size for container %s: %s", name, err)
+	}
+	return size
+}
+
+// Ulimit is a resource limit, which compose allows as a single limit or as
+// soft and hard limits
+type Ulimit struct {
+	Soft int `yaml:"soft"`
+	Hard int `yaml:"hard"`
+}
+
+// longUlimit has Ulimit's fields without its custom (un)marshaling
+type longUlimit Ulimit
+
+// UnmarshalYAML implements a custom unmarshal to accommodate both of compose's
+// ulimit forms
+func (u *Ulimit) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var limit int
+	if err := unmarshal(&limit); err == nil {
+		u.Soft, u.Hard = limit, limit
+		return nil
+	}
+	return unmarshal((*longUlimit)(u))
+}
+
+// MarshalYAML emits a single limit when the soft and hard limits are the same
+func (u Ulimit) MarshalYAML() (interface{}, error) {
+	if u.Soft == u.Hard {
+		return u.Soft, nil
+	}
+	return longUlimit(u), nil
+}
+
+func (c Container) ingestUlimits() []transform.Ulimit {
+	names := []string{}
+	for name := range c.Ulimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	response := []transform.Ulimit{}
+	for _, name := range names {
+		limit := c.Ulimits[name]
+		response = append(response, transform.Ulimit{Name: name, Soft: limit.Soft, Hard: limit.Hard})
+	}
+	if len(response) == 0 {
+		return nil
+	}
+	return response
+}
+
+func (c *Container) emitUlimits(ulimits []transform.Ulimit) {
+	for _, ulimit := range ulimits {
+		if c.Ulimits == nil {
+			c.Ulimits = map[string]Ulimit{}
+		}
+		c.Ulimits[ulimit.Name] = Ulimit{Soft: ulimit.Soft, Hard: ulimit.Hard}
+	}
+}
+
+// HealthTest is a health check's test, which compose allows as a list or as
+// a string to run with the container's shell
+type HealthTest []string
+
+// UnmarshalYAML implements a custom unmarshal to accommodate both of compose's
+// health check test forms
+func (ht *HealthTest) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var shell string
+	if err := unmarshal(&shell); err == nil {
+		*ht = HealthTest{"CMD-SHELL", shell}
+		return nil
+	}
+	var test []string
+	if err := unmarshal(&test); err != nil {
+		return err
+	}
+	*ht = test
+	return nil
+}
+
+// Healthcheck is a health check type for compose
+type Healthcheck struct {
+	Test        HealthTest `yaml:"test,omitempty"`
+	Interval    string     `yaml:"interval,omitempty"`
+	Timeout     string     `yaml:"timeout,omitempty"`
+	Retries     int        `yaml:"retries,omitempty"`
+	StartPeriod string     `yaml:"start_period,omitempty"`
+	Disable     bool       `yaml:"disable,omitempty"`
+}
+
+// ingestNoHealthCheck reports whether the service disables its image's health check
+func (c Container) ingestNoHealthCheck() bool {
+	if c.Healthcheck == nil {
+		return false
+	}
+	return c.Healthcheck.Disable || (len(c.Healthcheck.Test) > 0 && strings.ToUpper(c.Healthcheck.Test[0]) == "NONE")
+}
+
+func (c Container) ingestHealthChecks(name string) []*transform.HealthCheck {
+	if c.Healthcheck == nil || c.Healthcheck.Disable {
+		return nil
+	}
+	exec, execForm := transform.HealthCheckExec(c.Healthcheck.Test)
+	if len(exec) == 0 {
+		return nil
+	}
+	seconds := func(duration string) int {
+		seconds, err := transform.ParseSeconds(duration)
+		if err != nil {
+			log.Printf("Ignoring invalid health check duration %s for container %s: %s", duration, name, err)
+		}
+		return seconds
+	}
+	return []*transform.HealthCheck{{
+		Exec:             exec,
+		ExecForm:         execForm,
+		Interval:         seconds(c.Healthcheck.Interval),
+		Timeout:          seconds(c.Healthcheck.Timeout),
+		StartPeriod:      seconds(c.Healthcheck.StartPeriod),
+		FailureThreshold: c.Healthcheck.Retries,
+	}}
+}
+
+func (c *Container) emitHealthChecks(container transform.Container) {
+	check := container.ExecHealthCheck()
+	if check == nil {
+		if len(container.HealthChecks) > 0 {
+			log.Printf("Compose only runs command health checks, dropping health checks for container %s", container.Name)
+		}
+		if container.NoHealthCheck {
+			c.Healthcheck = &Healthcheck{Disable: true}
+		}
+		return
+	}
+	c.Healthcheck = &Healthcheck{
+		Test:        transform.HealthCheckTest(check),
+		Interval:    transform.FormatSeconds(check.Interval),
+		Timeout:     transform.FormatSeconds(check.Timeout),
+		Retries:     check.FailureThreshold,
+		StartPeriod: transform.FormatSeconds(check.StartPeriod),
+	}
+}
+
+// ServiceSecret is a secret granted to a service, in either the short syntax
+// of the secret's name or the long syntax
+type ServiceSecret struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target,omitempty"`
+	UID    string `yaml:"uid,omitempty"`
+	GID    string `yaml:"gid,omitempty"`
+	Mode   int    `yaml:"mode,omitempty"`
+}
+
+// longSecret has ServiceSecret's fields without its custom (un)marshaling
+type longSecret ServiceSecret
+
+// UnmarshalYAML implements a custom unmarshal to accommodate both of compose's
+// short and long secret syntaxes
+func (s *ServiceSecret) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&s.Source); err == nil {
+		return nil
+	}
+	return unmarshal((*longSecret)(s))
+}
+
+// MarshalYAML emits a secret in the short syntax unless it has more than a name
+func (s ServiceSecret) MarshalYAML() (interface{}, error) {
+	if s == (ServiceSecret{Source: s.Source}) {
+		return s.Source, nil
+	}
+	return longSecret(s), nil
+}
+
+// External marks a secret as created outside of compose, either as a boolean
+// or, in files before version 3.5, as an object holding the secret's name
+type External struct {
+	External bool
+	Name     string
+}
+
+// UnmarshalYAML implements a custom unmarshal to accommodate both forms of external
+func (e *External) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&e.External); err == nil {
+		return nil
+	}
+	var external struct {
+		Name string `yaml:"name"`
+	}
+	if err := unmarshal(&external); err != nil {
+		return err
+	}
+	e.External, e.Name = true, external.Name
+	return nil
+}
+
+// MarshalYAML emits external as a boolean, leaving the name to the secret
+func (e External) MarshalYAML() (interface{}, error) {
+	return e.External, nil
+}
+
+// Secret is a top-level compose secret
+type Secret struct {
+	File     string    `yaml:"file,omitempty"`
+	External *External `yaml:"external,omitempty"`
+	Name     string    `yaml:"name,omitempty"`
+}
+
+func (c Container) ingestSecrets() []transform.Secret {
+	response := []transform.Secret{}
+	for _, secret := range c.Secrets {
+		response = append(response, transform.Secret{
+			Source: secret.Source,
+			Target: secret.Target,
+			UID:    secret.UID,
+			GID:    secret.GID,
+			Mode:   secret.Mode,
+		})
+	}
+	if len(response) == 0 {
+		return nil
+	}
+	return response
+}
+
+func (c *Container) emitSecrets(secrets []transform.Secret) {
+	for _, secret := range secrets {
+		c.Secrets = append(c.Secrets, ServiceSecret{
+			Source: secret.Source,
+			Target: secret.Target,
+			UID:    secret.UID,
+			GID:    secret.GID,
+			Mode:   secret.Mode,
+		})
+	}
+}
+
+func (dc DockerCompose) ingestSecrets() map[string]*transform.SecretSource {
+	if len(dc.Secrets) == 0 {
+		return nil
+	}
+	response := map[string]*transform.SecretSource{}
+	for name, secret := range dc.Secrets {
+		source := &transform.SecretSource{File: secret.File, Name: secret.Name}
+		if secret.External != nil && secret.External.External {
+			source.External = true
+			if len(source.Name) == 0 {
+				source.Name = secret.External.Name
+			}
+		}
+		response[name] = source
+	}
+	return response
+}
+
+// emitSecrets adds the pod's secrets. Secrets read from an ARN are declared
+// external, since compose can only read secrets from files or the swarm
+func (dc *DockerCompose) emitSecrets(secrets map[string]*transform.SecretSource) {
+	for name, source := range secrets {
+		if dc.Secrets == nil {
+			dc.Secrets = map[string]*Secret{}
+		}
+		secret := &Secret{File: source.File, Name: source.Name}
+		if source.External || len(source.File) == 0 {
+			if !source.External {
+				log.Printf("Compose cannot read secret %s from %s, declaring it external", name, source.ValueFrom)
+			}
+			secret.External = &External{External: true}
+		}
+		dc.Secrets[name] = secret
+	}
+}
+
 // UnmarshalYAML allows for deserializing compose's "k=v" and "k: v" formats
 func (kv *KV) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	err := unmarshal(&kv.Values)
@@ -194,7 +612,12 @@ func (kv *KV) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
-// KV is a special type for Labels and Environment variables
+// MarshalYAML emits the "k: v" format
+func (kv KV) MarshalYAML() (interface{}, error) {
+	return kv.Values, nil
+}
+
+// KV is a special type for Labels, Environment variables, and sysctls
 // since compose allows "k=v" and "k: v" formats
 type KV struct {
 	Values map[string]string
@@ -247,37 +670,52 @@ func (c *Container) emitVolumes(vols *transform.IntermediateVolumes) {
 
 // Container is a type for deserializing docker-compose containers
 type Container struct {
-	Build        *Build   `yaml:"build,omitempty"`
-	Command      string   `yaml:"command,omitempty"`
-	CPU          int      `yaml:"cpu_shares,omitempty"`
-	DNS          []string `yaml:"dns,omitempty"`
-	Domain       []string `yaml:"dns_search,omitempty"`
-	Entrypoint   string   `yaml:"entrypoint,omitempty"`
-	EnvFile      []string `yaml:"env_file,omitempty"`
-	Environment  KV       `yaml:"environment,omitempty"`
-	Expose       []int    `yaml:"expose,omitempty"`
-	Hostname     string   `yaml:"hostname,omitempty"`
-	Image        string   `yaml:"image,omitempty"`
-	Labels       KV       `yaml:"labels,omitempty"`
-	Links        []string `yaml:"links,omitempty"`
-	Logging      *Logging `yaml:"logging,omitempty"`
-	Memory       int      `yaml:"mem_limit,omitempty"`
-	Name         string   `yaml:"-"`
-	Network      []string `yaml:"networks,omitempty"`
-	NetworkMode  string   `yaml:"network_mode,omitempty"`
-	Pid          string   `yaml:"pid,omitempty"`
-	PortMappings []string `yaml:"ports,omitempty"`
-	Privileged   bool     `yaml:"privileged,omitempty"`
-	User         string   `yaml:"user,omitempty"`
-	Volumes      []string `yaml:"volumes,omitempty"`
-	VolumesFrom  []string `yaml:"volumes_from,omitempty"`
-	WorkDir      string   `yaml:"working_dir,omitempty"`
+	Build        *Build            `yaml:"build,omitempty"`
+	CapAdd       []string          `yaml:"cap_add,omitempty"`
+	CapDrop      []string          `yaml:"cap_drop,omitempty"`
+	Command      Command           `yaml:"command,omitempty"`
+	CPU          int               `yaml:"cpu_shares,omitempty"`
+	Deploy       *Deploy           `yaml:"deploy,omitempty"`
+	Devices      []string          `yaml:"devices,omitempty"`
+	DNS          []string          `yaml:"dns,omitempty"`
+	Domain       []string          `yaml:"dns_search,omitempty"`
+	Entrypoint   Command           `yaml:"entrypoint,omitempty"`
+	EnvFile      []string          `yaml:"env_file,omitempty"`
+	Environment  KV                `yaml:"environment,omitempty"`
+	Expose       []int             `yaml:"expose,omitempty"`
+	Healthcheck  *Healthcheck      `yaml:"healthcheck,omitempty"`
+	Hostname     string            `yaml:"hostname,omitempty"`
+	Image        string            `yaml:"image,omitempty"`
+	Init         bool              `yaml:"init,omitempty"`
+	Labels       KV                `yaml:"labels,omitempty"`
+	Links        []string          `yaml:"links,omitempty"`
+	Logging      *Logging          `yaml:"logging,omitempty"`
+	Memory       int               `yaml:"mem_limit,omitempty"`
+	Name         string            `yaml:"-"`
+	Network      []string          `yaml:"networks,omitempty"`
+	NetworkMode  string            `yaml:"network_mode,omitempty"`
+	Pid          string            `yaml:"pid,omitempty"`
+	PortMappings []Port            `yaml:"ports,omitempty"`
+	Privileged   bool              `yaml:"privileged,omitempty"`
+	ReadOnly     bool              `yaml:"read_only,omitempty"`
+	Restart      string            `yaml:"restart,omitempty"`
+	Secrets      []ServiceSecret   `yaml:"secrets,omitempty"`
+	SecurityOpt  []string          `yaml:"security_opt,omitempty"`
+	ShmSize      string            `yaml:"shm_size,omitempty"`
+	Sysctls      KV                `yaml:"sysctls,omitempty"`
+	Tmpfs        StringList        `yaml:"tmpfs,omitempty"`
+	Ulimits      map[string]Ulimit `yaml:"ulimits,omitempty"`
+	User         string            `yaml:"user,omitempty"`
+	Volumes      []string          `yaml:"volumes,omitempty"`
+	VolumesFrom  []string          `yaml:"volumes_from,omitempty"`
+	WorkDir      string            `yaml:"working_dir,omitempty"`
 }
 
 // DockerCompose implements InputFormat and OutputFormat
 type DockerCompose struct {
 	Version  string                `yaml:"version"`
 	Services map[string]*Container `yaml:"services"`
+	Secrets  map[string]*Secret    `yaml:"secrets,omitempty"`
 }
 
 // IngestContainers satisfies InputFormat so docker-compose containers can be ingested
@@ -301,16 +739,21 @@ func (dc DockerCompose) IngestContainers(input io.ReadCloser) (*transform.PodDat
 
 		ir := transform.Container{}
 		ir.Build = container.ingestBuild()
+		ir.CapAdd = container.CapAdd
+		ir.CapDrop = container.CapDrop
 		ir.Command = container.Command
 		ir.CPU = container.CPU
+		ir.Devices = container.ingestDevices()
 		ir.DNS = container.DNS
 		ir.Domain = container.Domain
 		ir.Entrypoint = container.Entrypoint
 		ir.EnvFile = container.EnvFile
 		ir.Environment = container.Environment.Values
 		ir.Expose = container.Expose
+		ir.HealthChecks = container.ingestHealthChecks(serviceName)
 		ir.Hostname = container.Hostname
 		ir.Image = container.Image
+		ir.Init = container.Init
 		ir.Labels = container.Labels.Values
 		ir.Links = container.Links
 		ir.Logging = container.ingestLogging()
@@ -318,9 +761,18 @@ func (dc DockerCompose) IngestContainers(input io.ReadCloser) (*transform.PodDat
 		ir.Name = serviceName
 		ir.Network = container.Network
 		ir.NetworkMode = container.NetworkMode
+		ir.NoHealthCheck = container.ingestNoHealthCheck()
 		ir.Pid = container.Pid
 		ir.PortMappings = container.ingestPortMappings()
 		ir.Privileged = container.Privileged
+		ir.ReadOnly = container.ReadOnly
+		ir.Restart = container.Restart
+		ir.Secrets = container.ingestSecrets()
+		ir.SecurityOpt = container.SecurityOpt
+		ir.ShmSize = container.ingestShmSize(serviceName)
+		ir.Sysctls = container.Sysctls.Values
+		ir.Tmpfs = container.ingestTmpfs(serviceName)
+		ir.Ulimits = container.ingestUlimits()
 		ir.User = container.User
 		ir.Volumes = container.ingestVolumes()
 		ir.VolumesFrom = container.VolumesFrom
@@ -330,42 +782,94 @@ func (dc DockerCompose) IngestContainers(input io.ReadCloser) (*transform.PodDat
 	}
 	sort.Sort(containers)
 	outputPod.Containers = &containers
+	outputPod.Secrets = dc.ingestSecrets()
 	return &outputPod, nil
 }
 
+// emitContainer converts an intermediate container into a compose service
+func emitContainer(container transform.Container) *Container {
+	composeContainer := &Container{}
+	composeContainer.emitBuild(container.Build)
+	composeContainer.CapAdd = container.CapAdd
+	composeContainer.CapDrop = container.CapDrop
+	composeContainer.Command = container.CommandArgs()
+	composeContainer.CPU = container.CPU
+	composeContainer.emitDevices(container.Devices)
+	composeContainer.DNS = container.DNS
+	composeContainer.Domain = container.Domain
+	composeContainer.Entrypoint = container.Entrypoint
+	composeContainer.EnvFile = container.EnvFile
+	composeContainer.Environment = KV{Values: container.Environment}
+	composeContainer.Expose = container.Expose
+	composeContainer.emitHealthChecks(container)
+	composeContainer.Hostname = container.Hostname
+	composeContainer.Image = container.Image
+	composeContainer.Init = container.Init
+	composeContainer.Labels = KV{Values: container.Labels}
+	composeContainer.Links = container.Links
+	composeContainer.emitLogging(container.Logging)
+	composeContainer.Memory = container.Memory
+	composeContainer.Network = container.Network
+	composeContainer.NetworkMode = container.NetworkMode
+	composeContainer.Pid = container.Pid
+	composeContainer.emitPortMappings(container.PortMappings)
+	composeContainer.Privileged = container.Privileged
+	composeContainer.ReadOnly = container.ReadOnly
+	composeContainer.Restart = container.Restart
+	composeContainer.emitSecrets(container.Secrets)
+	composeContainer.SecurityOpt = container.SecurityOpt
+	if container.ShmSize > 0 {
+		composeContainer.ShmSize = transform.FormatBytes(container.ShmSize)
+	}
+	composeContainer.Sysctls = KV{Values: container.Sysctls}
+	composeContainer.emitTmpfs(container.Tmpfs)
+	composeContainer.emitUlimits(container.Ulimits)
+	composeContainer.User = container.User
+	composeContainer.emitVolumes(container.Volumes)
+	composeContainer.VolumesFrom = container.VolumesFrom
+	composeContainer.WorkDir = container.WorkDir
+	return composeContainer
+}
+
 // EmitContainers satisfies OutputFormat so docker-compose containers can be emitted
 func (dc DockerCompose) EmitContainers(input *transform.PodData) ([]byte, error) {
 	output := &DockerCompose{Version: "2"}
 	output.Services = map[string]*Container{}
 
 	for _, container := range *input.Containers {
-		composeContainer := Container{}
-		output.Services[container.Name] = &composeContainer
-
-		composeContainer.emitBuild(container.Build)
-		composeContainer.Command = container.Command
-		composeContainer.CPU = container.CPU
-		composeContainer.DNS = container.DNS
-		composeContainer.Domain = container.Domain
-		composeContainer.Entrypoint = container.Entrypoint
-		composeContainer.EnvFile = container.EnvFile
-		composeContainer.Environment = KV{Values: container.Environment}
-		composeContainer.Expose = container.Expose
-		composeContainer.Hostname = container.Hostname
-		composeContainer.Image = container.Image
-		composeContainer.Labels = KV{Values: container.Labels}
-		composeContainer.Links = container.Links
-		composeContainer.emitLogging(container.Logging)
-		composeContainer.Memory = container.Memory
-		composeContainer.Network = container.Network
-		composeContainer.NetworkMode = container.NetworkMode
-		composeContainer.Pid = container.Pid
-		composeContainer.emitPortMappings(container.PortMappings)
-		composeContainer.Privileged = container.Privileged
-		composeContainer.User = container.User
-		composeContainer.emitVolumes(container.Volumes)
-		composeContainer.VolumesFrom = container.VolumesFrom
-		composeContainer.WorkDir = container.WorkDir
+		composeContainer := emitContainer(container)
+		output.Services[container.Name] = composeContainer
+		switch {
+		case composeContainer.Healthcheck != nil && !composeContainer.Healthcheck.Disable:
+			// Health checks, with start_period, require version 2.3
+			output.Version = "2.3"
+		case composeContainer.Init && output.Version < "2.2":
+			// init requires version 2.2
+			output.Version = "2.2"
+		case (composeContainer.Healthcheck != nil || len(composeContainer.Sysctls.Values) > 0) && output.Version < "2.1":
+			// Disabling health checks and sysctls require version 2.1
+			output.Version = "2.1"
+		}
+	}
+	output.emitSecrets(input.Secrets)
+	if len(output.Secrets) > 0 {
+		output.emitVersion3()
 	}
 	return yaml.Marshal(output)
 }
+
+// emitVersion3 raises the file to version 3.8, since version 2 files cannot
+// declare secrets. Version 3 has no cpu_shares, mem_limit, or volumes_from
+func (dc *DockerCompose) emitVersion3() {
+	dc.Version = "3.8"
+	for name, service := range dc.Services {
+		if service.CPU > 0 || service.Memory > 0 {
+			service.Deploy = &Deploy{}
+			service.emitResources()
+		}
+		if len(service.VolumesFrom) > 0 {
+			log.Printf("Compose version 3 does not support volumes_from, dropping volumes_from for container %s", name)
+			service.VolumesFrom = nil
+		}
+	}
+}
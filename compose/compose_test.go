@@ -1,8 +1,14 @@
 package compose
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
 	"testing"
+
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
 )
 
 func TestIngestContainers(t *testing.T) {
@@ -38,3 +44,320 @@ func TestEmitContainers(t *testing.T) {
 	}
 
 }
+
+func TestEmitMapsAndBuild(t *testing.T) {
+	bp := &transform.PodData{Containers: &transform.Containers{{
+		Name:        "web",
+		Build:       &transform.BuildContext{Context: "./app"},
+		Environment: map[string]string{"PGHOST": "db"},
+		Labels:      map[string]string{"com.example.team": "web"},
+	}}}
+
+	got, err := DockerCompose{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	assert.Equal(t, `version: "2"
+services:
+  web:
+    build: ./app
+    environment:
+      PGHOST: db
+    labels:
+      com.example.team: web
+`, string(got))
+}
+
+func TestEmitStack(t *testing.T) {
+	cf := DockerCompose{}
+
+	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := cf.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+	bp.Replicas = 2
+	bp.GlobalLabels = map[string]string{"com.example.stack": "accounting"}
+
+	got, err := Stack{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/stack.yaml")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	body := `version: "2.3"
+services:
+  db:
+    image: postgres
+    healthcheck:
+      test: ["CMD", "pg_isready", "-U", "postgres"]
+      interval: 1m30s
+      timeout: 10s
+      retries: 3
+      start_period: 40s
+  web:
+    image: nginx
+    healthcheck:
+      test: curl -f http://localhost/ || exit 1
+  worker:
+    image: worker
+    healthcheck:
+      disable: true
+`
+
+	bp, err := DockerCompose{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	containers := *bp.Containers
+	assert.Equal(t, []*transform.HealthCheck{{
+		Exec:             "pg_isready -U postgres",
+		ExecForm:         true,
+		Interval:         90,
+		Timeout:          10,
+		StartPeriod:      40,
+		FailureThreshold: 3,
+	}}, containers[0].HealthChecks)
+	assert.Equal(t, []*transform.HealthCheck{{Exec: "curl -f http://localhost/ || exit 1"}}, containers[1].HealthChecks)
+	assert.Nil(t, containers[2].HealthChecks)
+	assert.True(t, containers[2].NoHealthCheck)
+
+	got, err := DockerCompose{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	assert.Contains(t, string(got), `version: "2.3"`)
+	assert.Contains(t, string(got), `    healthcheck:
+      test:
+      - CMD
+      - pg_isready
+      - -U
+      - postgres
+      interval: 1m30s
+      timeout: 10s
+      retries: 3
+      start_period: 40s
+`)
+	assert.Contains(t, string(got), `  worker:
+    healthcheck:
+      disable: true
+`)
+}
+
+func TestCommandForms(t *testing.T) {
+	body := `version: "2"
+services:
+  string:
+    image: alpine
+    command: sh -c "echo hi" 'it''s' \$HOME
+  list:
+    image: alpine
+    entrypoint: ["/bin/my app"]
+    command: ["sh", "-c", "echo hi"]
+`
+
+	bp, err := DockerCompose{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	containers := *bp.Containers
+	assert.Equal(t, []string{"sh", "-c", "echo hi"}, containers[0].Command)
+	assert.Equal(t, []string{"/bin/my app"}, containers[0].Entrypoint)
+	assert.Equal(t, []string{"sh", "-c", "echo hi", "its", "$HOME"}, containers[1].Command)
+
+	shell := transform.Container{Command: []string{"echo hi > /tmp/out"}, ShellForm: true}
+	assert.Equal(t, Command{"/bin/sh", "-c", "echo hi > /tmp/out"}, emitContainer(shell).Command)
+
+	_, err = DockerCompose{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(`services:
+  web:
+    command: echo 'unterminated
+`)))
+	assert.Error(t, err)
+}
+
+const secretsFixture = `version: "3.1"
+services:
+  db:
+    image: postgres
+    secrets:
+    - db_password
+    - source: tls_key
+      target: server.key
+      uid: "103"
+      gid: "103"
+      mode: 0440
+secrets:
+  db_password:
+    file: ./db_password.txt
+  tls_key:
+    external:
+      name: prod_tls_key
+`
+
+func TestSecrets(t *testing.T) {
+	bp, err := DockerCompose{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(secretsFixture)))
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, []transform.Secret{
+		{Source: "db_password"},
+		{Source: "tls_key", Target: "server.key", UID: "103", GID: "103", Mode: 0440},
+	}, (*bp.Containers)[0].Secrets)
+	assert.Equal(t, map[string]*transform.SecretSource{
+		"db_password": {File: "./db_password.txt"},
+		"tls_key":     {External: true, Name: "prod_tls_key"},
+	}, bp.Secrets)
+
+	got, err := DockerCompose{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	assert.Contains(t, string(got), `version: "3.8"
+`)
+	assert.Contains(t, string(got), `    secrets:
+    - db_password
+    - source: tls_key
+      target: server.key
+      uid: "103"
+      gid: "103"
+      mode: 288
+`)
+	assert.Contains(t, string(got), `secrets:
+  db_password:
+    file: ./db_password.txt
+  tls_key:
+    external: true
+    name: prod_tls_key
+`)
+
+	(*bp.Containers)[0].CPU = 512
+	(*bp.Containers)[0].Memory = 268435456
+	(*bp.Containers)[0].VolumesFrom = []string{"data"}
+	got, err = DockerCompose{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	assert.Contains(t, string(got), `    deploy:
+      resources:
+        limits:
+          cpus: "0.5"
+          memory: 256M
+`)
+	assert.NotContains(t, string(got), "cpu_shares")
+	assert.NotContains(t, string(got), "mem_limit")
+	assert.NotContains(t, string(got), "volumes_from")
+}
+
+func TestLinuxOptions(t *testing.T) {
+	body := `version: "2.2"
+services:
+  web:
+    image: nginx
+    devices:
+    - /dev/sda:/dev/xvda:rwm
+    shm_size: 67108864
+    tmpfs: /run
+`
+
+	bp, err := DockerCompose{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	container := (*bp.Containers)[0]
+	assert.Equal(t, []transform.Device{{Host: "/dev/sda", Container: "/dev/xvda", Permissions: "rwm"}}, container.Devices)
+	assert.Equal(t, 64<<20, container.ShmSize)
+	assert.Equal(t, []transform.Tmpfs{{Container: "/run"}}, container.Tmpfs)
+
+	composeContainer := emitContainer(container)
+	assert.Equal(t, []string{"/dev/sda:/dev/xvda:rwm"}, composeContainer.Devices)
+	assert.Equal(t, "64M", composeContainer.ShmSize)
+	assert.Equal(t, StringList{"/run"}, composeContainer.Tmpfs)
+}
+
+func TestUlimitsAndSysctls(t *testing.T) {
+	body := `version: "2.1"
+services:
+  db:
+    image: postgres
+    sysctls:
+    - net.core.somaxconn=1024
+    ulimits:
+      nproc: 65535
+      nofile:
+        soft: 1024
+        hard: 2048
+`
+
+	bp, err := DockerCompose{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	container := (*bp.Containers)[0]
+	assert.Equal(t, map[string]string{"net.core.somaxconn": "1024"}, container.Sysctls)
+	assert.Equal(t, []transform.Ulimit{
+		{Name: "nofile", Soft: 1024, Hard: 2048},
+		{Name: "nproc", Soft: 65535, Hard: 65535},
+	}, container.Ulimits)
+
+	got, err := DockerCompose{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	assert.Equal(t, `version: "2.1"
+services:
+  db:
+    image: postgres
+    sysctls:
+      net.core.somaxconn: "1024"
+    ulimits:
+      nofile:
+        soft: 1024
+        hard: 2048
+      nproc: 65535
+`, string(got))
+}
+
+func TestIngestLongPorts(t *testing.T) {
+	body := `version: "3.8"
+services:
+  web:
+    image: nginx
+    ports:
+    - "8080:80"
+    - target: 53
+      published: 5353
+      protocol: udp
+      mode: host
+`
+
+	bp, err := DockerCompose{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, &transform.PortMappings{
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostPort: 5353, ContainerPort: 53, Protocol: "udp"},
+	}, (*bp.Containers)[0].PortMappings)
+}
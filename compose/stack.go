@@ -0,0 +1,133 @@
+package compose
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/micahhausler/container-tx/transform"
+	"gopkg.in/yaml.v2"
+)
+
+// ResourceLimits is a type for storing a swarm service's resource limits
+type ResourceLimits struct {
+	CPUs   string `yaml:"cpus,omitempty"`
+	Memory string `yaml:"memory,omitempty"`
+}
+
+// Resources is a type for storing a swarm service's resources
+type Resources struct {
+	Limits *ResourceLimits `yaml:"limits,omitempty"`
+}
+
+// Deploy is a type for storing a swarm service's deploy section
+type Deploy struct {
+	Replicas  int               `yaml:"replicas,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+	Resources *Resources        `yaml:"resources,omitempty"`
+}
+
+// emitResources moves cpu shares and the memory limit into deploy resource
+// limits, since swarm ignores cpu_shares and mem_limit
+func (c *Container) emitResources() {
+	if c.CPU == 0 && c.Memory == 0 {
+		return
+	}
+	limits := &ResourceLimits{}
+	if c.CPU > 0 {
+		limits.CPUs = strconv.FormatFloat(float64(c.CPU)/1024, 'f', -1, 64)
+	}
+	if c.Memory > 0 {
+		limits.Memory = transform.FormatBytes(c.Memory)
+	}
+	c.Deploy.Resources = &Resources{Limits: limits}
+	c.CPU = 0
+	c.Memory = 0
+}
+
+// emitStackPorts converts port mappings into the long port syntax. Ports bound
+// to a host IP are published in host mode, others through the ingress network
+func (c *Container) emitStackPorts(name string, mappings *transform.PortMappings) {
+	c.PortMappings = nil
+	if mappings == nil {
+		return
+	}
+	for _, mapping := range *mappings {
+		if mapping.ContainerPort == 0 {
+			continue
+		}
+		port := longPort{
+			Target:    mapping.ContainerPort,
+			Published: mapping.HostPort,
+			Protocol:  mapping.Protocol,
+			Mode:      "ingress",
+		}
+		if len(mapping.HostIP) > 0 {
+			log.Printf("Swarm cannot bind to host IP %s for container %s, publishing port %d in host mode", mapping.HostIP, name, mapping.HostPort)
+			port.Mode = "host"
+		}
+		c.PortMappings = append(c.PortMappings, Port{Long: port})
+	}
+}
+
+// Stack represents a Docker Swarm stack file, a version 3 compose file with
+// deploy settings. It implements OutputFormat
+type Stack struct{}
+
+// EmitContainers satisfies OutputFormat so swarm stacks can be emitted
+func (s Stack) EmitContainers(input *transform.PodData) ([]byte, error) {
+	output := &DockerCompose{Version: "3.8"}
+	output.Services = map[string]*Container{}
+
+	for _, container := range *input.Containers {
+		composeContainer := emitContainer(container)
+		output.Services[container.Name] = composeContainer
+
+		composeContainer.Deploy = &Deploy{
+			Replicas: container.Replicas,
+			Labels:   input.GlobalLabels,
+		}
+		if composeContainer.Deploy.Replicas == 0 {
+			composeContainer.Deploy.Replicas = input.Replicas
+		}
+		composeContainer.emitResources()
+		if deploy := composeContainer.Deploy; deploy.Replicas == 0 && len(deploy.Labels) == 0 && deploy.Resources == nil {
+			composeContainer.Deploy = nil
+		}
+		composeContainer.emitStackPorts(container.Name, container.PortMappings)
+
+		if len(composeContainer.Links) > 0 {
+			log.Printf("Swarm ignores links, dropping links for container %s", container.Name)
+			composeContainer.Links = nil
+		}
+		if len(composeContainer.VolumesFrom) > 0 {
+			log.Printf("Swarm does not support volumes_from, dropping volumes_from for container %s", container.Name)
+			composeContainer.VolumesFrom = nil
+		}
+		if len(composeContainer.Restart) > 0 {
+			log.Printf("Swarm ignores restart, dropping restart %s for container %s", composeContainer.Restart, container.Name)
+			composeContainer.Restart = ""
+		}
+		if len(composeContainer.CapAdd) > 0 || len(composeContainer.CapDrop) > 0 || len(composeContainer.Devices) > 0 || len(composeContainer.SecurityOpt) > 0 {
+			log.Printf("Swarm ignores cap_add, cap_drop, devices, and security_opt, dropping them for container %s", container.Name)
+			composeContainer.CapAdd, composeContainer.CapDrop = nil, nil
+			composeContainer.Devices, composeContainer.SecurityOpt = nil, nil
+		}
+		if composeContainer.Build != nil {
+			log.Printf("Swarm ignores build, dropping build for container %s", container.Name)
+			if len(composeContainer.Image) == 0 {
+				log.Printf("Container %s has no image, push its build to a registry and set its image before deploying", container.Name)
+			}
+			composeContainer.Build = nil
+		}
+		if composeContainer.Privileged {
+			log.Printf("Swarm ignores privileged, dropping privileged for container %s", container.Name)
+			composeContainer.Privileged = false
+		}
+		if len(composeContainer.NetworkMode) > 0 {
+			log.Printf("Swarm ignores network_mode, dropping network_mode %s for container %s", composeContainer.NetworkMode, container.Name)
+			composeContainer.NetworkMode = ""
+		}
+	}
+	output.emitSecrets(input.Secrets)
+	return yaml.Marshal(output)
+}
@@ -0,0 +1,221 @@
+package docker
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// EndpointSettings configures a container's connection to a network
+type EndpointSettings struct {
+	Aliases []string `json:"Aliases,omitempty"`
+}
+
+// NetworkingConfig configures the network a container is connected to on creation
+type NetworkingConfig struct {
+	EndpointsConfig map[string]*EndpointSettings `json:"EndpointsConfig"`
+}
+
+// CreateRequest is the body of a POST /containers/create request. The
+// container's name is passed separately, in the name query parameter
+type CreateRequest struct {
+	Config
+	HostConfig       *HostConfig       `json:"HostConfig"`
+	NetworkingConfig *NetworkingConfig `json:"NetworkingConfig,omitempty"`
+}
+
+// sortedKeys returns a map's keys in order
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// emitLink converts a compose link, db or db:alias, into the form name:alias
+func emitLink(link string) string {
+	if strings.Contains(link, ":") {
+		return link
+	}
+	return link + ":" + link
+}
+
+// dependencyOrder orders containers so each comes after the containers it
+// depends on. Containers in a dependency cycle keep their original order
+func dependencyOrder(containers transform.Containers) transform.Containers {
+	byName := map[string]transform.Container{}
+	for _, container := range containers {
+		byName[container.Name] = container
+	}
+
+	ordered := transform.Containers{}
+	visited := map[string]bool{}
+	var visit func(container transform.Container)
+	visit = func(container transform.Container) {
+		if visited[container.Name] {
+			return
+		}
+		visited[container.Name] = true
+		for _, dep := range container.Dependencies() {
+			if depContainer, ok := byName[dep]; ok {
+				visit(depContainer)
+			}
+		}
+		ordered = append(ordered, container)
+	}
+	for _, container := range containers {
+		visit(container)
+	}
+	return ordered
+}
+
+func (r *CreateRequest) emitPorts(container transform.Container) {
+	ports := map[string]struct{}{}
+	for _, port := range container.Expose {
+		ports[portKey(port, "tcp")] = struct{}{}
+	}
+	if container.PortMappings != nil {
+		bindings := map[string][]PortBinding{}
+		for _, mapping := range *container.PortMappings {
+			key := portKey(mapping.ContainerPort, mapping.Protocol)
+			ports[key] = struct{}{}
+			binding := PortBinding{HostIP: mapping.HostIP}
+			if mapping.HostPort > 0 {
+				binding.HostPort = strconv.Itoa(mapping.HostPort)
+			}
+			bindings[key] = append(bindings[key], binding)
+		}
+		r.HostConfig.PortBindings = bindings
+	}
+	if len(ports) > 0 {
+		r.ExposedPorts = ports
+	}
+}
+
+// emitVolumes converts volumes with a host path or name into binds, and
+// anonymous volumes into the container's Volumes
+func (r *CreateRequest) emitVolumes(container transform.Container) {
+	if container.Volumes == nil {
+		return
+	}
+	for _, volume := range *container.Volumes {
+		source := volume.NamedVolume()
+		if len(source) == 0 {
+			source = volume.Host
+		}
+		if len(source) == 0 {
+			if r.Volumes == nil {
+				r.Volumes = map[string]struct{}{}
+			}
+			r.Volumes[volume.Container] = struct{}{}
+			continue
+		}
+		if strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~") {
+			log.Printf("The Docker Engine API requires absolute host paths, %s for container %s must be made absolute", source, container.Name)
+		}
+		bind := source + ":" + volume.Container
+		if volume.ReadOnly {
+			bind += ":ro"
+		}
+		r.HostConfig.Binds = append(r.HostConfig.Binds, bind)
+	}
+}
+
+// emitNetworking sets the network aliases of a container on the network it
+// is created on. Docker only supports aliases on user defined networks
+func (r *CreateRequest) emitNetworking(container transform.Container) {
+	if len(container.Network) == 0 {
+		return
+	}
+	network := container.NetworkMode
+	if len(network) == 0 || network == "bridge" || network == "default" || network == "host" || network == "none" || strings.HasPrefix(network, "container:") {
+		log.Printf("Docker only supports network aliases on user defined networks, dropping aliases for container %s", container.Name)
+		return
+	}
+	r.NetworkingConfig = &NetworkingConfig{
+		EndpointsConfig: map[string]*EndpointSettings{
+			network: {Aliases: container.Network},
+		},
+	}
+}
+
+// emitContainer converts an intermediate container into a create request
+func emitContainer(container transform.Container) *CreateRequest {
+	request := &CreateRequest{HostConfig: &HostConfig{}}
+	request.Cmd = container.CommandArgs()
+	request.Entrypoint = container.Entrypoint
+	for _, k := range sortedKeys(container.Environment) {
+		request.Env = append(request.Env, k+"="+container.Environment[k])
+	}
+	request.Healthcheck = emitHealthCheck(container)
+	request.Hostname = container.Hostname
+	request.Image = container.Image
+	request.Labels = container.Labels
+	request.StopSignal = container.StopSignal
+	request.User = container.User
+	request.WorkingDir = container.WorkDir
+	request.emitPorts(container)
+	request.emitVolumes(container)
+	request.emitNetworking(container)
+
+	request.HostConfig.CPUShares = container.CPU
+	request.HostConfig.DNS = container.DNS
+	request.HostConfig.DNSSearch = container.Domain
+	for _, link := range container.Links {
+		request.HostConfig.Links = append(request.HostConfig.Links, emitLink(link))
+	}
+	if container.Logging != nil {
+		request.HostConfig.LogConfig = &LogConfig{
+			Type:   container.Logging.Driver,
+			Config: container.Logging.Options,
+		}
+	}
+	request.HostConfig.Memory = container.Memory
+	request.HostConfig.NetworkMode = container.NetworkMode
+	request.HostConfig.PidMode = container.Pid
+	request.HostConfig.Privileged = container.Privileged
+	request.HostConfig.RestartPolicy = emitRestartPolicy(container.Restart)
+	request.HostConfig.VolumesFrom = container.VolumesFrom
+	return request
+}
+
+// Create represents the Docker Engine API container create requests for a
+// set of containers. It implements OutputFormat and FileOutputFormat
+type Create struct{}
+
+// EmitFiles satisfies FileOutputFormat so each create request can be written
+// to its own file
+func (c Create) EmitFiles(input *transform.PodData) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for _, container := range *input.Containers {
+		body, err := json.MarshalIndent(emitContainer(container), "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		files[container.Name+".json"] = body
+	}
+	return files, nil
+}
+
+// NamedRequest is a create request along with the name of the container it creates
+type NamedRequest struct {
+	Name string         `json:"Name"`
+	Body *CreateRequest `json:"Body"`
+}
+
+// EmitContainers satisfies OutputFormat so create requests can be emitted.
+// Requests are listed in the order the containers must be created, so each
+// follows the containers it links to or mounts volumes from
+func (c Create) EmitContainers(input *transform.PodData) ([]byte, error) {
+	requests := []NamedRequest{}
+	for _, container := range dependencyOrder(*input.Containers) {
+		requests = append(requests, NamedRequest{Name: container.Name, Body: emitContainer(container)})
+	}
+	return json.MarshalIndent(requests, "", "    ")
+}
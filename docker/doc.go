@@ -0,0 +1,2 @@
+// Package docker for converting containers to and from the Docker Engine API
+package docker
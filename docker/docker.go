@@ -0,0 +1,205 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// Config is the portable configuration of a container, shared by the Docker
+// Engine API's inspect and create endpoints
+type Config struct {
+	Hostname     string              `json:"Hostname,omitempty"`
+	Domainname   string              `json:"Domainname,omitempty"`
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Healthcheck  *HealthConfig       `json:"Healthcheck,omitempty"`
+	Image        string              `json:"Image"`
+	Volumes      map[string]struct{} `json:"Volumes,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	StopSignal   string              `json:"StopSignal,omitempty"`
+}
+
+// HealthConfig is a container's health check, with durations in nanoseconds
+type HealthConfig struct {
+	Test        []string      `json:"Test,omitempty"`
+	Interval    time.Duration `json:"Interval,omitempty"`
+	Timeout     time.Duration `json:"Timeout,omitempty"`
+	StartPeriod time.Duration `json:"StartPeriod,omitempty"`
+	Retries     int           `json:"Retries,omitempty"`
+}
+
+// PortBinding is a host address a container port is published on
+type PortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// LogConfig is a container's logging driver and its options
+type LogConfig struct {
+	Type   string            `json:"Type"`
+	Config map[string]string `json:"Config,omitempty"`
+}
+
+// RestartPolicy is the policy for restarting a container when it exits
+type RestartPolicy struct {
+	Name              string `json:"Name"`
+	MaximumRetryCount int    `json:"MaximumRetryCount,omitempty"`
+}
+
+// HostConfig is the host specific configuration of a container
+type HostConfig struct {
+	Binds         []string                 `json:"Binds,omitempty"`
+	LogConfig     *LogConfig               `json:"LogConfig,omitempty"`
+	NetworkMode   string                   `json:"NetworkMode,omitempty"`
+	PortBindings  map[string][]PortBinding `json:"PortBindings,omitempty"`
+	RestartPolicy *RestartPolicy           `json:"RestartPolicy,omitempty"`
+	VolumesFrom   []string                 `json:"VolumesFrom,omitempty"`
+	DNS           []string                 `json:"Dns,omitempty"`
+	DNSSearch     []string                 `json:"DnsSearch,omitempty"`
+	Links         []string                 `json:"Links,omitempty"`
+	PidMode       string                   `json:"PidMode,omitempty"`
+	Privileged    bool                     `json:"Privileged,omitempty"`
+	CPUShares     int                      `json:"CpuShares,omitempty"`
+	Memory        int                      `json:"Memory,omitempty"`
+}
+
+// portKey returns the key of a port in ExposedPorts and PortBindings, such as 80/tcp
+func portKey(port int, protocol string) string {
+	if len(protocol) == 0 {
+		protocol = "tcp"
+	}
+	return strconv.Itoa(port) + "/" + strings.ToLower(protocol)
+}
+
+// parsePortKey parses a port key such as 80/tcp into its port and protocol
+func parsePortKey(key string) (int, string, error) {
+	parts := strings.SplitN(key, "/", 2)
+	port, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid port %s", key)
+	}
+	if len(parts) == 1 {
+		return port, "tcp", nil
+	}
+	return port, parts[1], nil
+}
+
+// emitRestartPolicy converts a restart policy such as on-failure:5
+func emitRestartPolicy(policy string) *RestartPolicy {
+	if len(policy) == 0 {
+		return nil
+	}
+	parts := strings.SplitN(policy, ":", 2)
+	response := &RestartPolicy{Name: parts[0]}
+	if len(parts) == 2 {
+		response.MaximumRetryCount, _ = strconv.Atoi(parts[1])
+	}
+	return response
+}
+
+// ingestRestartPolicy converts a restart policy into the form docker run takes
+func ingestRestartPolicy(policy *RestartPolicy) string {
+	if policy == nil || len(policy.Name) == 0 || policy.Name == "no" {
+		return ""
+	}
+	if policy.MaximumRetryCount > 0 {
+		return policy.Name + ":" + strconv.Itoa(policy.MaximumRetryCount)
+	}
+	return policy.Name
+}
+
+// ingestHealthCheck converts a health check, dropping durations below a second
+func ingestHealthCheck(hc *HealthConfig) []*transform.HealthCheck {
+	if hc == nil {
+		return nil
+	}
+	exec, execForm := transform.HealthCheckExec(hc.Test)
+	if len(exec) == 0 {
+		return nil
+	}
+	return []*transform.HealthCheck{{
+		Exec:             exec,
+		ExecForm:         execForm,
+		Interval:         int(hc.Interval / time.Second),
+		Timeout:          int(hc.Timeout / time.Second),
+		StartPeriod:      int(hc.StartPeriod / time.Second),
+		FailureThreshold: hc.Retries,
+	}}
+}
+
+// emitHealthCheck converts the container's first command health check
+func emitHealthCheck(container transform.Container) *HealthConfig {
+	check := container.ExecHealthCheck()
+	if check == nil {
+		if len(container.HealthChecks) > 0 {
+			log.Printf("Docker only runs command health checks, dropping health checks for container %s", container.Name)
+		}
+		if container.NoHealthCheck {
+			return &HealthConfig{Test: []string{"NONE"}}
+		}
+		return nil
+	}
+	return &HealthConfig{
+		Test:        transform.HealthCheckTest(check),
+		Interval:    time.Duration(check.Interval) * time.Second,
+		Timeout:     time.Duration(check.Timeout) * time.Second,
+		StartPeriod: time.Duration(check.StartPeriod) * time.Second,
+		Retries:     check.FailureThreshold,
+	}
+}
+
+// ingestVolume parses a bind in the form host:container[:options]
+func ingestVolume(bind string) transform.IntermediateVolume {
+	iv := transform.IntermediateVolume{}
+	parts := strings.Split(bind, ":")
+	if len(parts) == 1 {
+		iv.Container = parts[0]
+		return iv
+	}
+	iv.Host = parts[0]
+	iv.Container = parts[1]
+	if len(parts) > 2 {
+		for _, option := range strings.Split(parts[2], ",") {
+			if option == "ro" {
+				iv.ReadOnly = true
+			}
+		}
+	}
+	return iv
+}
+
+// ingestLink converts an engine link, /db:/web/alias, into the compose form db:alias
+func ingestLink(link string) string {
+	parts := strings.SplitN(link, ":", 2)
+	name := strings.TrimPrefix(parts[0], "/")
+	if len(parts) == 1 {
+		return name
+	}
+	alias := parts[1][strings.LastIndex(parts[1], "/")+1:]
+	if alias == name {
+		return name
+	}
+	return name + ":" + alias
+}
+
+// ingestEnv converts a list of KEY=value pairs into a map
+func ingestEnv(env []string) map[string]string {
+	if len(env) == 0 {
+		return nil
+	}
+	response := map[string]string{}
+	for _, pair := range env {
+		parts := append(strings.SplitN(pair, "=", 2), "")
+		response[parts[0]] = parts[1]
+	}
+	return response
+}
@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestIngestInspect(t *testing.T) {
+	f, err := os.Open("./test_fixtures/inspect.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := Inspect{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := compose.DockerCompose{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/inspect.yaml")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestIngestLink(t *testing.T) {
+	assert.Equal(t, "db", ingestLink("/db:/web/db"))
+	assert.Equal(t, "db:database", ingestLink("/db:/web/database"))
+	assert.Equal(t, "db", ingestLink("/db"))
+}
+
+func TestRestartPolicy(t *testing.T) {
+	assert.Equal(t, "on-failure:5", ingestRestartPolicy(emitRestartPolicy("on-failure:5")))
+	assert.Equal(t, "always", ingestRestartPolicy(emitRestartPolicy("always")))
+	assert.Equal(t, "", ingestRestartPolicy(&RestartPolicy{Name: "no"}))
+	assert.Nil(t, emitRestartPolicy(""))
+}
+
+func TestEmitCreate(t *testing.T) {
+	f, err := os.Open("./test_fixtures/inspect.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := Inspect{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := Create{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/create.json")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestDependencyOrder(t *testing.T) {
+	containers := transform.Containers{
+		{Name: "app", Links: []string{"db:database"}, VolumesFrom: []string{"data:ro"}},
+		{Name: "data"},
+		{Name: "db", VolumesFrom: []string{"data"}},
+		{Name: "web", Links: []string{"app"}},
+	}
+	names := []string{}
+	for _, container := range dependencyOrder(containers) {
+		names = append(names, container.Name)
+	}
+	assert.Equal(t, []string{"data", "db", "app", "web"}, names)
+}
+
+func TestEmitNetworking(t *testing.T) {
+	request := emitContainer(transform.Container{Name: "web", Network: []string{"www"}})
+	assert.Nil(t, request.NetworkingConfig)
+
+	request = emitContainer(transform.Container{Name: "web", Network: []string{"www"}, NetworkMode: "frontend"})
+	assert.Equal(t, []string{"www"}, request.NetworkingConfig.EndpointsConfig["frontend"].Aliases)
+}
@@ -0,0 +1,195 @@
+package docker
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// anonymousVolume matches the generated names of anonymous volumes
+var anonymousVolume = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// Mount is a volume or bind mount of an inspected container
+type Mount struct {
+	Type        string `json:"Type"`
+	Name        string `json:"Name,omitempty"`
+	Source      string `json:"Source"`
+	Destination string `json:"Destination"`
+	RW          bool   `json:"RW"`
+}
+
+// InspectedContainer is a single container in the output of docker inspect
+type InspectedContainer struct {
+	ID         string      `json:"Id"`
+	Name       string      `json:"Name"`
+	Config     *Config     `json:"Config"`
+	HostConfig *HostConfig `json:"HostConfig"`
+	Mounts     []Mount     `json:"Mounts"`
+}
+
+func (ic InspectedContainer) ingestPortMappings() (*transform.PortMappings, []int) {
+	mappings := transform.PortMappings{}
+	expose := []int{}
+	for key, bindings := range ic.HostConfig.PortBindings {
+		port, protocol, err := parsePortKey(key)
+		if err != nil {
+			log.Printf("Skipping port binding %s for container %s: %s", key, ic.Name, err)
+			continue
+		}
+		for _, binding := range bindings {
+			mapping := transform.PortMapping{HostIP: binding.HostIP, ContainerPort: port, Protocol: protocol}
+			mapping.HostPort, _ = strconv.Atoi(binding.HostPort)
+			mappings = append(mappings, mapping)
+		}
+	}
+	for key := range ic.Config.ExposedPorts {
+		if _, ok := ic.HostConfig.PortBindings[key]; ok {
+			continue
+		}
+		port, _, err := parsePortKey(key)
+		if err != nil {
+			log.Printf("Skipping exposed port %s for container %s: %s", key, ic.Name, err)
+			continue
+		}
+		expose = append(expose, port)
+	}
+	sort.Ints(expose)
+	if len(expose) == 0 {
+		expose = nil
+	}
+	if len(mappings) == 0 {
+		return nil, expose
+	}
+	sort.Sort(mappings)
+	return &mappings, expose
+}
+
+// ingestVolumes converts the container's binds, followed by any mounts not
+// covered by a bind such as anonymous volumes
+func (ic InspectedContainer) ingestVolumes() *transform.IntermediateVolumes {
+	volumes := transform.IntermediateVolumes{}
+	seen := map[string]bool{}
+	for _, bind := range ic.HostConfig.Binds {
+		volume := ingestVolume(bind)
+		seen[volume.Container] = true
+		volumes = append(volumes, volume)
+	}
+	for _, mount := range ic.Mounts {
+		if seen[mount.Destination] {
+			continue
+		}
+		volume := transform.IntermediateVolume{Container: mount.Destination, ReadOnly: !mount.RW}
+		switch {
+		case mount.Type == "bind":
+			volume.Host = mount.Source
+		case mount.Type == "volume" && !anonymousVolume.MatchString(mount.Name):
+			volume.Host = mount.Name
+		case mount.Type != "volume":
+			log.Printf("Skipping %s mount %s for container %s", mount.Type, mount.Destination, ic.Name)
+			continue
+		}
+		volumes = append(volumes, volume)
+	}
+	if len(volumes) == 0 {
+		return nil
+	}
+	sort.Sort(volumes)
+	return &volumes
+}
+
+// ingestLogging converts the container's log driver, leaving out docker's
+// default json-file driver when it has no options
+func (ic InspectedContainer) ingestLogging() *transform.Logging {
+	config := ic.HostConfig.LogConfig
+	if config == nil || len(config.Type) == 0 || (config.Type == "json-file" && len(config.Config) == 0) {
+		return nil
+	}
+	return &transform.Logging{
+		Driver:  ic.HostConfig.LogConfig.Type,
+		Options: ic.HostConfig.LogConfig.Config,
+	}
+}
+
+// ingestContainer converts an inspected container. Settings docker fills in
+// by default, such as a hostname from the container ID, are left out
+func (ic InspectedContainer) ingestContainer() transform.Container {
+	if ic.Config == nil {
+		ic.Config = &Config{}
+	}
+	if ic.HostConfig == nil {
+		ic.HostConfig = &HostConfig{}
+	}
+
+	ir := transform.Container{}
+	ir.Command = ic.Config.Cmd
+	ir.CPU = ic.HostConfig.CPUShares
+	ir.DNS = ic.HostConfig.DNS
+	ir.Domain = ic.HostConfig.DNSSearch
+	ir.Entrypoint = ic.Config.Entrypoint
+	ir.Environment = ingestEnv(ic.Config.Env)
+	ir.HealthChecks = ingestHealthCheck(ic.Config.Healthcheck)
+	if !strings.HasPrefix(ic.ID, ic.Config.Hostname) {
+		ir.Hostname = ic.Config.Hostname
+	}
+	ir.Image = ic.Config.Image
+	if len(ic.Config.Labels) > 0 {
+		ir.Labels = ic.Config.Labels
+	}
+	for _, link := range ic.HostConfig.Links {
+		ir.Links = append(ir.Links, ingestLink(link))
+	}
+	ir.Logging = ic.ingestLogging()
+	ir.Memory = ic.HostConfig.Memory
+	ir.Name = strings.TrimPrefix(ic.Name, "/")
+	if ic.HostConfig.NetworkMode != "default" {
+		ir.NetworkMode = ic.HostConfig.NetworkMode
+	}
+	ir.NoHealthCheck = ic.Config.Healthcheck != nil && len(ic.Config.Healthcheck.Test) > 0 && ic.Config.Healthcheck.Test[0] == "NONE"
+	ir.Pid = ic.HostConfig.PidMode
+	ir.PortMappings, ir.Expose = ic.ingestPortMappings()
+	ir.Privileged = ic.HostConfig.Privileged
+	ir.Restart = ingestRestartPolicy(ic.HostConfig.RestartPolicy)
+	ir.StopSignal = ic.Config.StopSignal
+	ir.User = ic.Config.User
+	ir.Volumes = ic.ingestVolumes()
+	ir.VolumesFrom = ic.HostConfig.VolumesFrom
+	ir.WorkDir = ic.Config.WorkingDir
+	return ir
+}
+
+// Inspect represents the output of docker inspect for one or more containers.
+// Inspect merges the image's environment, command, entrypoint, exposed ports,
+// and volumes into each container, so they are pinned in the output. It
+// implements InputFormat
+type Inspect []InspectedContainer
+
+// IngestContainers satisfies InputFormat so inspected containers can be ingested
+func (i Inspect) IngestContainers(input io.ReadCloser) (*transform.PodData, error) {
+
+	body, err := ioutil.ReadAll(input)
+	defer input.Close()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	err = json.Unmarshal(body, &i)
+	if err != nil {
+		return nil, err
+	}
+
+	outputPod := transform.PodData{}
+
+	containers := transform.Containers{}
+	for _, container := range i {
+		containers = append(containers, container.ingestContainer())
+	}
+	sort.Sort(containers)
+	outputPod.Containers = &containers
+	return &outputPod, nil
+}
@@ -0,0 +1,110 @@
+package ecs
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+	"gopkg.in/yaml.v2"
+)
+
+var nonAlphanumeric = regexp.MustCompile("[^a-zA-Z0-9]+")
+
+// logicalID derives a CloudFormation logical ID from a pod's name
+func logicalID(name string) string {
+	id := ""
+	for _, part := range nonAlphanumeric.Split(name, -1) {
+		if len(part) > 0 {
+			id += strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	return id + "TaskDefinition"
+}
+
+// freeformProperties are task definition properties whose keys are user
+// defined, and so keep their case
+var freeformProperties = map[string]bool{
+	"dockerLabels": true,
+	"options":      true,
+}
+
+// cloudFormationProperties converts a task definition's JSON properties into
+// CloudFormation's capitalized property names, dropping null properties
+func cloudFormationProperties(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		response := map[string]interface{}{}
+		for k, item := range value {
+			if item == nil {
+				continue
+			}
+			name := strings.ToUpper(k[:1]) + k[1:]
+			if freeformProperties[k] {
+				response[name] = item
+			} else {
+				response[name] = cloudFormationProperties(item)
+			}
+		}
+		return response
+	case []interface{}:
+		response := []interface{}{}
+		for _, item := range value {
+			response = append(response, cloudFormationProperties(item))
+		}
+		return response
+	}
+	return v
+}
+
+// CloudFormationResource is a type for storing a CloudFormation resource
+type CloudFormationResource struct {
+	Type       string      `json:"Type" yaml:"Type"`
+	Properties interface{} `json:"Properties" yaml:"Properties"`
+}
+
+// CloudFormationTemplate is a type for storing a CloudFormation template
+type CloudFormationTemplate struct {
+	AWSTemplateFormatVersion string                            `json:"AWSTemplateFormatVersion" yaml:"AWSTemplateFormatVersion"`
+	Resources                map[string]CloudFormationResource `json:"Resources" yaml:"Resources"`
+}
+
+// CloudFormation represents a CloudFormation template with an
+// AWS::ECS::TaskDefinition resource, in YAML unless JSON is set. It
+// implements OutputFormat
+type CloudFormation struct {
+	JSON bool
+}
+
+// EmitContainers satisfies OutputFormat so CloudFormation templates can be emitted
+func (cf CloudFormation) EmitContainers(input *transform.PodData) ([]byte, error) {
+	task := emitTask(input)
+	if len(task.Family) == 0 {
+		task.Family = logicalID(input.Name)
+	}
+
+	body, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	var properties interface{}
+	err = json.Unmarshal(body, &properties)
+	if err != nil {
+		return nil, err
+	}
+
+	output := CloudFormationTemplate{
+		AWSTemplateFormatVersion: "2010-09-09",
+		Resources: map[string]CloudFormationResource{
+			logicalID(input.Name): {
+				Type:       "AWS::ECS::TaskDefinition",
+				Properties: cloudFormationProperties(properties),
+			},
+		},
+	}
+
+	if cf.JSON {
+		return json.MarshalIndent(output, "", "    ")
+	}
+	return yaml.Marshal(output)
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"io"
 	"io/ioutil"
+	"log"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -72,6 +74,317 @@ type Logging struct {
 	Options map[string]string `json:"options"`
 }
 
+// HealthCheck is a type for storing an ECS container health check
+type HealthCheck struct {
+	Command     []string `json:"command"`
+	Interval    int      `json:"interval,omitempty"`
+	Timeout     int      `json:"timeout,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+	StartPeriod int      `json:"startPeriod,omitempty"`
+}
+
+func (c Container) ingestHealthChecks() []*transform.HealthCheck {
+	if c.HealthCheck == nil {
+		return nil
+	}
+	exec, execForm := transform.HealthCheckExec(c.HealthCheck.Command)
+	if len(exec) == 0 {
+		return nil
+	}
+	return []*transform.HealthCheck{{
+		Exec:             exec,
+		ExecForm:         execForm,
+		Interval:         c.HealthCheck.Interval,
+		Timeout:          c.HealthCheck.Timeout,
+		StartPeriod:      c.HealthCheck.StartPeriod,
+		FailureThreshold: c.HealthCheck.Retries,
+	}}
+}
+
+func (c *Container) emitHealthChecks(container transform.Container) {
+	check := container.ExecHealthCheck()
+	if check == nil {
+		if len(container.HealthChecks) > 0 {
+			log.Printf("ECS only runs command health checks, dropping health checks for container %s", container.Name)
+		}
+		if container.NoHealthCheck {
+			log.Printf("ECS cannot disable the image's health check for container %s", container.Name)
+		}
+		return
+	}
+	c.HealthCheck = &HealthCheck{
+		Command:     transform.HealthCheckTest(check),
+		Interval:    check.Interval,
+		Timeout:     check.Timeout,
+		Retries:     check.FailureThreshold,
+		StartPeriod: check.StartPeriod,
+	}
+}
+
+// Secret is a secret ECS reads from an SSM parameter or Secrets Manager
+// secret, and injects into the container as an environment variable
+type Secret struct {
+	Name      string `json:"name"`
+	ValueFrom string `json:"valueFrom"`
+}
+
+// ingestSecrets converts the container's secrets, adding the ARNs they are
+// read from to secrets. Secrets are named after their environment variable,
+// prefixed with the container's name if another container reads a different
+// ARN into the same variable
+func (c Container) ingestSecrets(secrets map[string]*transform.SecretSource) []transform.Secret {
+	response := []transform.Secret{}
+	for _, secret := range c.Secrets {
+		name := secret.Name
+		if source, ok := secrets[name]; ok && source.ValueFrom != secret.ValueFrom {
+			name = c.Name + "_" + secret.Name
+		}
+		secrets[name] = &transform.SecretSource{ValueFrom: secret.ValueFrom}
+		ir := transform.Secret{Source: name}
+		if name != secret.Name {
+			ir.Target = secret.Name
+		}
+		response = append(response, ir)
+	}
+	if len(response) == 0 {
+		return nil
+	}
+	return response
+}
+
+var invalidEnvChars = regexp.MustCompile("[^a-zA-Z0-9_]+")
+
+// envName replaces the characters an environment variable name can't hold
+// with underscores, and prefixes names that start with a digit
+func envName(name string) string {
+	name = invalidEnvChars.ReplaceAllString(name, "_")
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// emitSecrets adds the container's secrets that have an ARN. Each is named
+// after its target, unless the target is a path rather than a variable name
+func (c *Container) emitSecrets(container transform.Container, secrets map[string]*transform.SecretSource) {
+	for _, secret := range container.Secrets {
+		source, ok := secrets[secret.Source]
+		if !ok || len(source.ValueFrom) == 0 {
+			log.Printf("No ARN for secret %s, dropping it from container %s. Map secrets to ARNs with --secret-arns", secret.Source, container.Name)
+			continue
+		}
+		if len(secret.UID) > 0 || len(secret.GID) > 0 || secret.Mode > 0 {
+			log.Printf("ECS injects secrets as environment variables, dropping the uid, gid, and mode of secret %s for container %s", secret.Source, container.Name)
+		}
+		name := secret.Target
+		if len(name) == 0 || strings.Contains(name, "/") {
+			name = secret.Source
+		}
+		if valid := envName(name); valid != name {
+			log.Printf("%s is not a valid environment variable name, renaming secret %s to %s for container %s", name, secret.Source, valid, container.Name)
+			name = valid
+		}
+		log.Printf("ECS injects secrets as environment variables, secret %s is read from $%s rather than a file in container %s", secret.Source, name, container.Name)
+		c.Secrets = append(c.Secrets, Secret{Name: name, ValueFrom: source.ValueFrom})
+	}
+}
+
+// KernelCapabilities are the capabilities added to or dropped from a container
+type KernelCapabilities struct {
+	Add  []string `json:"add,omitempty"`
+	Drop []string `json:"drop,omitempty"`
+}
+
+// Device is a host device added to a container
+type Device struct {
+	HostPath      string   `json:"hostPath"`
+	ContainerPath string   `json:"containerPath,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+}
+
+// Tmpfs is a tmpfs mount, with its size in MiB
+type Tmpfs struct {
+	ContainerPath string   `json:"containerPath"`
+	Size          int      `json:"size"`
+	MountOptions  []string `json:"mountOptions,omitempty"`
+}
+
+// LinuxParameters are the linux specific options of a container
+type LinuxParameters struct {
+	Capabilities       *KernelCapabilities `json:"capabilities,omitempty"`
+	Devices            []Device            `json:"devices,omitempty"`
+	InitProcessEnabled bool                `json:"initProcessEnabled,omitempty"`
+	SharedMemorySize   int                 `json:"sharedMemorySize,omitempty"`
+	Tmpfs              []Tmpfs             `json:"tmpfs,omitempty"`
+}
+
+// devicePermissions maps docker's device permissions to ECS's
+var devicePermissions = []struct {
+	docker string
+	ecs    string
+}{{"r", "read"}, {"w", "write"}, {"m", "mknod"}}
+
+// defaultTmpfsSize is the size in MiB of tmpfs mounts without one, which ECS requires
+const defaultTmpfsSize = 64
+
+func (c Container) ingestLinuxParameters(ir *transform.Container) {
+	lp := c.LinuxParameters
+	if lp == nil {
+		return
+	}
+	if lp.Capabilities != nil {
+		ir.CapAdd = lp.Capabilities.Add
+		ir.CapDrop = lp.Capabilities.Drop
+	}
+	for _, device := range lp.Devices {
+		irDevice := transform.Device{Host: device.HostPath, Container: device.ContainerPath}
+		if len(irDevice.Container) == 0 {
+			irDevice.Container = device.HostPath
+		}
+		for _, permission := range devicePermissions {
+			for _, p := range device.Permissions {
+				if p == permission.ecs {
+					irDevice.Permissions += permission.docker
+				}
+			}
+		}
+		ir.Devices = append(ir.Devices, irDevice)
+	}
+	ir.Init = lp.InitProcessEnabled
+	ir.ShmSize = lp.SharedMemorySize << 20
+	for _, tmpfs := range lp.Tmpfs {
+		ir.Tmpfs = append(ir.Tmpfs, transform.Tmpfs{
+			Container: tmpfs.ContainerPath,
+			Size:      tmpfs.Size << 20,
+			Options:   tmpfs.MountOptions,
+		})
+	}
+}
+
+// emitLinuxParameters sets the container's capabilities, devices, init,
+// shared memory, and tmpfs mounts. ECS measures sizes in MiB
+func (c *Container) emitLinuxParameters(container transform.Container) {
+	lp := &LinuxParameters{}
+	if len(container.CapAdd) > 0 || len(container.CapDrop) > 0 {
+		lp.Capabilities = &KernelCapabilities{
+			Add:  emitCapabilities(container.CapAdd),
+			Drop: emitCapabilities(container.CapDrop),
+		}
+	}
+	for _, device := range container.Devices {
+		ecsDevice := Device{HostPath: device.Host, ContainerPath: device.Container}
+		for _, permission := range devicePermissions {
+			if strings.Contains(device.Permissions, permission.docker) {
+				ecsDevice.Permissions = append(ecsDevice.Permissions, permission.ecs)
+			}
+		}
+		lp.Devices = append(lp.Devices, ecsDevice)
+	}
+	lp.InitProcessEnabled = container.Init
+	lp.SharedMemorySize = (container.ShmSize + 1<<20 - 1) >> 20
+	for _, tmpfs := range container.Tmpfs {
+		size := (tmpfs.Size + 1<<20 - 1) >> 20
+		if size == 0 {
+			log.Printf("ECS requires a tmpfs size, using %d MiB for tmpfs %s in container %s", defaultTmpfsSize, tmpfs.Container, container.Name)
+			size = defaultTmpfsSize
+		}
+		lp.Tmpfs = append(lp.Tmpfs, Tmpfs{
+			ContainerPath: tmpfs.Container,
+			Size:          size,
+			MountOptions:  tmpfs.Options,
+		})
+	}
+	if lp.Capabilities != nil || len(lp.Devices) > 0 || lp.InitProcessEnabled || lp.SharedMemorySize > 0 || len(lp.Tmpfs) > 0 {
+		c.LinuxParameters = lp
+	}
+}
+
+// emitCapabilities converts capabilities into ECS's names, such as SYS_ADMIN
+func emitCapabilities(capabilities []string) []string {
+	response := []string{}
+	for _, capability := range capabilities {
+		response = append(response, strings.TrimPrefix(strings.ToUpper(capability), "CAP_"))
+	}
+	if len(response) == 0 {
+		return nil
+	}
+	return response
+}
+
+// securityOptions are the docker security options ECS supports
+var securityOptions = map[string]bool{"apparmor": true, "credentialspec": true, "label": true, "no-new-privileges": true}
+
+// emitSecurityOptions converts the container's security options into ECS's
+// name:value form, dropping those ECS does not support
+func (c *Container) emitSecurityOptions(container transform.Container) {
+	for _, opt := range container.SecurityOpt {
+		parts := strings.SplitN(strings.Replace(opt, "=", ":", 1), ":", 2)
+		if !securityOptions[parts[0]] {
+			log.Printf("ECS does not support security option %s, dropping it for container %s", opt, container.Name)
+			continue
+		}
+		if parts[0] == "no-new-privileges" {
+			if len(parts) == 1 || parts[1] != "false" {
+				c.DockerSecurityOptions = append(c.DockerSecurityOptions, parts[0])
+			}
+			continue
+		}
+		c.DockerSecurityOptions = append(c.DockerSecurityOptions, strings.Join(parts, ":"))
+	}
+}
+
+// SystemControl is a namespaced kernel parameter set in a container
+type SystemControl struct {
+	Namespace string `json:"namespace"`
+	Value     string `json:"value"`
+}
+
+func (c Container) ingestSystemControls() map[string]string {
+	if len(c.SystemControls) == 0 {
+		return nil
+	}
+	response := map[string]string{}
+	for _, control := range c.SystemControls {
+		response[control.Namespace] = control.Value
+	}
+	return response
+}
+
+func (c *Container) emitSystemControls(sysctls map[string]string) {
+	namespaces := []string{}
+	for namespace := range sysctls {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		c.SystemControls = append(c.SystemControls, SystemControl{Namespace: namespace, Value: sysctls[namespace]})
+	}
+}
+
+// Ulimit is a resource limit of a container
+type Ulimit struct {
+	Name      string `json:"name"`
+	SoftLimit int    `json:"softLimit"`
+	HardLimit int    `json:"hardLimit"`
+}
+
+func (c Container) ingestUlimits() []transform.Ulimit {
+	response := []transform.Ulimit{}
+	for _, ulimit := range c.Ulimits {
+		response = append(response, transform.Ulimit{Name: ulimit.Name, Soft: ulimit.SoftLimit, Hard: ulimit.HardLimit})
+	}
+	if len(response) == 0 {
+		return nil
+	}
+	return response
+}
+
+func (c *Container) emitUlimits(ulimits []transform.Ulimit) {
+	for _, ulimit := range ulimits {
+		c.Ulimits = append(c.Ulimits, Ulimit{Name: ulimit.Name, SoftLimit: ulimit.Soft, HardLimit: ulimit.Hard})
+	}
+}
+
 func (c Container) ingestMemory() int {
 	var memoryIn = c.Memory << 20
 	if memoryIn == 0 {
@@ -142,6 +455,9 @@ func (c Container) ingestVolumes(volumeMap map[string]string) *transform.Interme
 				ReadOnly:  vol.ReadOnly,
 				Host:      volumeMap[vol.SourceVolume],
 			}
+			if len(iv.Host) == 0 {
+				iv.SourceVolume = vol.SourceVolume
+			}
 			response = append(response, iv)
 		}
 		return &response
@@ -155,6 +471,14 @@ func (c *Container) emitVolumes(vols *transform.IntermediateVolumes) map[string]
 		mountPoints := MountPoints{}
 		for _, volume := range *vols {
 			sourceVolume := strings.Trim(strings.Replace(volume.Host, "/", "-", -1), "-")
+			if len(volume.Host) == 0 {
+				// Volumes without a host path are docker volumes, named after
+				// their mount point when they have no name
+				sourceVolume = volume.SourceVolume
+				if len(sourceVolume) == 0 {
+					sourceVolume = strings.Trim(strings.Replace(volume.Container, "/", "-", -1), "-")
+				}
+			}
 			response[sourceVolume] = volume.Host
 			mountPoints = append(mountPoints, MountPoint{
 				SourceVolume:  sourceVolume,
@@ -233,27 +557,34 @@ func (evf VolumesFrom) Less(i, j int) bool {
 
 // Container represents the ECS container information
 type Container struct {
-	Command      []string          `json:"command,omitempty"`
-	CPU          int               `json:"cpu,omitempty"`
-	DNS          []string          `json:"dnsServers,omitempty"`
-	Domain       []string          `json:"dnsSearchDomains,omitempty"`
-	Entrypoint   []string          `json:"entryPoint,omitempty"`
-	Environment  *Environments     `json:"environment,omitempty"`
-	Essential    bool              `json:"essential,omitempty"`
-	Hostname     string            `json:"hostname,omitempty"`
-	Image        string            `json:"image" ctx:"required"`
-	Labels       map[string]string `json:"dockerLabels"`
-	Links        []string          `json:"links,omitempty"`
-	Logging      *Logging          `json:"logConfiguration,omitempty"`
-	Memory       int               `json:"memory" ctx:"required"`
-	Name         string            `json:"name" ctx:"required"`
-	NetworkMode  string            `json:"networkMode,omitempty"`
-	PortMappings *PortMappings     `json:"portMappings,omitempty"`
-	Privileged   bool              `json:"privileged,omitempty"`
-	User         string            `json:"user,omitempty"`
-	Volumes      *MountPoints      `json:"mountPoints,omitempty"`
-	VolumesFrom  *VolumesFrom      `json:"volumesFrom,omitempty"`
-	WorkDir      string            `json:"workingDirectory,omitempty"`
+	Command               []string          `json:"command,omitempty"`
+	CPU                   int               `json:"cpu,omitempty"`
+	DNS                   []string          `json:"dnsServers,omitempty"`
+	Domain                []string          `json:"dnsSearchDomains,omitempty"`
+	DockerSecurityOptions []string          `json:"dockerSecurityOptions,omitempty"`
+	Entrypoint            []string          `json:"entryPoint,omitempty"`
+	Environment           *Environments     `json:"environment,omitempty"`
+	Essential             bool              `json:"essential,omitempty"`
+	HealthCheck           *HealthCheck      `json:"healthCheck,omitempty"`
+	Hostname              string            `json:"hostname,omitempty"`
+	Image                 string            `json:"image" ctx:"required"`
+	Labels                map[string]string `json:"dockerLabels,omitempty"`
+	Links                 []string          `json:"links,omitempty"`
+	LinuxParameters       *LinuxParameters  `json:"linuxParameters,omitempty"`
+	Logging               *Logging          `json:"logConfiguration,omitempty"`
+	Memory                int               `json:"memory" ctx:"required"`
+	Name                  string            `json:"name" ctx:"required"`
+	NetworkMode           string            `json:"networkMode,omitempty"`
+	PortMappings          *PortMappings     `json:"portMappings,omitempty"`
+	Privileged            bool              `json:"privileged,omitempty"`
+	ReadOnly              bool              `json:"readonlyRootFilesystem,omitempty"`
+	Secrets               []Secret          `json:"secrets,omitempty"`
+	SystemControls        []SystemControl   `json:"systemControls,omitempty"`
+	Ulimits               []Ulimit          `json:"ulimits,omitempty"`
+	User                  string            `json:"user,omitempty"`
+	Volumes               *MountPoints      `json:"mountPoints,omitempty"`
+	VolumesFrom           *VolumesFrom      `json:"volumesFrom,omitempty"`
+	WorkDir               string            `json:"workingDirectory,omitempty"`
 }
 
 // Containers is a composite type for a slice of ECS Containers
@@ -273,7 +604,7 @@ type Volume struct {
 
 // VolumeHost is a type for storing task-level volume's host path
 type VolumeHost struct {
-	SourcePath string `json:"sourcePath"`
+	SourcePath string `json:"sourcePath,omitempty"`
 }
 
 // Volumes is a composite type for slices of ECS Volume
@@ -293,22 +624,98 @@ type Task struct {
 	Volumes              *Volumes    `json:"volumes"`
 }
 
-func volumesToMap(vols *Volumes) map[string]string {
+// VolumesToMap maps the names of task-level volumes to their host paths
+func VolumesToMap(vols *Volumes) map[string]string {
 	response := map[string]string{}
+	if vols == nil {
+		return response
+	}
 	for _, vol := range *vols {
 		response[vol.Name] = vol.Host.SourcePath
 	}
 	return response
 }
 
-func mapToVolumes(names map[string]string) *Volumes {
+// MapToVolumes converts volume names and host paths into sorted task-level volumes
+func MapToVolumes(names map[string]string) *Volumes {
 	response := Volumes{}
 	for name, path := range names {
 		response = append(response, Volume{Name: name, Host: VolumeHost{SourcePath: path}})
 	}
+	sort.Sort(response)
 	return &response
 }
 
+// IngestContainer converts an ECS container definition into an intermediate
+// container, resolving its mount points against the task-level volumes
+func IngestContainer(container Container, volumes map[string]string) transform.Container {
+	ir := transform.Container{}
+	ir.Command = container.Command
+	ir.CPU = container.CPU
+	ir.DNS = container.DNS
+	ir.Domain = container.Domain
+	ir.Entrypoint = container.Entrypoint
+	ir.Environment = container.ingestEnvironment()
+	ir.Essential = container.Essential
+	ir.HealthChecks = container.ingestHealthChecks()
+	ir.Hostname = container.Hostname
+	ir.Image = container.Image
+	ir.Labels = container.Labels
+	ir.Links = container.Links
+	container.ingestLinuxParameters(&ir)
+	ir.Logging = container.ingestLogging()
+	ir.Memory = container.ingestMemory()
+	ir.Name = container.Name
+	ir.NetworkMode = container.NetworkMode
+	ir.PortMappings = container.ingestPortMappings()
+	ir.Privileged = container.Privileged
+	ir.ReadOnly = container.ReadOnly
+	ir.SecurityOpt = container.DockerSecurityOptions
+	ir.Sysctls = container.ingestSystemControls()
+	ir.Ulimits = container.ingestUlimits()
+	ir.User = container.User
+	ir.Volumes = container.ingestVolumes(volumes)
+	ir.VolumesFrom = container.ingestVolumesFrom()
+	ir.WorkDir = container.WorkDir
+	return ir
+}
+
+// EmitContainer converts an intermediate container into an ECS container
+// definition, adding the task-level volumes it mounts to volumes
+func EmitContainer(container transform.Container, volumes map[string]string) Container {
+	EcsContainer := Container{}
+	EcsContainer.Command = container.CommandArgs()
+	EcsContainer.CPU = container.CPU
+	EcsContainer.DNS = container.DNS
+	EcsContainer.Domain = container.Domain
+	EcsContainer.emitSecurityOptions(container)
+	EcsContainer.Entrypoint = container.Entrypoint
+	EcsContainer.emitEnvironment(container.Environment)
+	EcsContainer.Essential = container.Essential
+	EcsContainer.emitHealthChecks(container)
+	EcsContainer.Hostname = container.Hostname
+	EcsContainer.Image = container.Image
+	EcsContainer.Labels = container.Labels
+	EcsContainer.Links = container.Links
+	EcsContainer.emitLinuxParameters(container)
+	EcsContainer.emitLogging(container.Logging)
+	EcsContainer.emitMemory(container.Memory)
+	EcsContainer.Name = container.Name
+	EcsContainer.NetworkMode = container.NetworkMode
+	EcsContainer.emitPortMappings(container.PortMappings)
+	EcsContainer.Privileged = container.Privileged
+	EcsContainer.ReadOnly = container.ReadOnly
+	EcsContainer.emitSystemControls(container.Sysctls)
+	EcsContainer.emitUlimits(container.Ulimits)
+	EcsContainer.User = container.User
+	for k, v := range EcsContainer.emitVolumes(container.Volumes) {
+		volumes[k] = v
+	}
+	EcsContainer.emitVolumesFrom(container.VolumesFrom)
+	EcsContainer.WorkDir = container.WorkDir
+	return EcsContainer
+}
+
 // IngestContainers satisfies InputFormat so ECS tasks can be ingested
 func (t Task) IngestContainers(input io.ReadCloser) (*transform.PodData, error) {
 
@@ -325,85 +732,43 @@ func (t Task) IngestContainers(input io.ReadCloser) (*transform.PodData, error)
 	outputPod := transform.PodData{Name: t.Family}
 	containers := transform.Containers{}
 
-	volMap := volumesToMap(t.Volumes)
+	volMap := VolumesToMap(t.Volumes)
+	secrets := map[string]*transform.SecretSource{}
 
 	for _, container := range *t.ContainerDefinitions {
-		ir := transform.Container{}
-		if len(container.Command) > 0 {
-			ir.Command = strings.Join(container.Command, " ")
-		}
-		ir.CPU = container.CPU
-		ir.DNS = container.DNS
-		ir.Domain = container.Domain
-		if len(container.Entrypoint) > 0 {
-			ir.Entrypoint = strings.Join(container.Entrypoint, " ")
-		}
-		ir.Environment = container.ingestEnvironment()
-		ir.Essential = container.Essential
-		ir.Hostname = container.Hostname
-		ir.Image = container.Image
-		ir.Labels = container.Labels
-		ir.Links = container.Links
-		ir.Logging = container.ingestLogging()
-		ir.Memory = container.ingestMemory()
-		ir.Name = container.Name
-		ir.NetworkMode = container.NetworkMode
-		ir.PortMappings = container.ingestPortMappings()
-		ir.Privileged = container.Privileged
-		ir.User = container.User
-		ir.Volumes = container.ingestVolumes(volMap)
-		ir.VolumesFrom = container.ingestVolumesFrom()
-		ir.WorkDir = container.WorkDir
+		ir := IngestContainer(container, volMap)
+		ir.Secrets = container.ingestSecrets(secrets)
 		containers = append(containers, ir)
 	}
 	sort.Sort(containers)
 	outputPod.Containers = &containers
+	if len(secrets) > 0 {
+		outputPod.Secrets = secrets
+	}
 
 	return &outputPod, nil
 }
 
-// EmitContainers satisfies OutputFormat so ECS tasks can be emitted
-func (t Task) EmitContainers(input *transform.PodData) ([]byte, error) {
+// emitTask converts a pod into an ECS task
+func emitTask(input *transform.PodData) *Task {
 	output := &Task{Family: input.Name}
 	containers := Containers{}
 
 	volumesMap := map[string]string{}
 
 	for _, container := range *input.Containers {
-		EcsContainer := Container{}
-		if len(container.Command) > 0 {
-			EcsContainer.Command = strings.Split(container.Command, " ")
-		}
-		EcsContainer.CPU = container.CPU
-		EcsContainer.DNS = container.DNS
-		EcsContainer.Domain = container.Domain
-		if len(container.Entrypoint) > 0 {
-			EcsContainer.Entrypoint = strings.Split(container.Entrypoint, " ")
-		}
-		EcsContainer.emitEnvironment(container.Environment)
-		EcsContainer.Hostname = container.Hostname
-		EcsContainer.Image = container.Image
-		EcsContainer.Labels = container.Labels
-		EcsContainer.Links = container.Links
-		EcsContainer.emitLogging(container.Logging)
-		EcsContainer.emitMemory(container.Memory)
-		EcsContainer.Name = container.Name
-		EcsContainer.NetworkMode = container.NetworkMode
-		EcsContainer.emitPortMappings(container.PortMappings)
-		EcsContainer.Privileged = container.Privileged
-		EcsContainer.User = container.User
-		for k, v := range EcsContainer.emitVolumes(container.Volumes) {
-			volumesMap[k] = v
-		}
-		EcsContainer.emitVolumesFrom(container.VolumesFrom)
-		EcsContainer.WorkDir = container.WorkDir
-		containers = append(containers, EcsContainer)
+		ecsContainer := EmitContainer(container, volumesMap)
+		ecsContainer.emitSecrets(container, input.Secrets)
+		containers = append(containers, ecsContainer)
 	}
-	output.Volumes = mapToVolumes(volumesMap)
-	sort.Sort(output.Volumes)
+	output.Volumes = MapToVolumes(volumesMap)
 
 	sort.Sort(containers)
 	output.ContainerDefinitions = &containers
+	return output
+}
 
-	return json.MarshalIndent(output, "", "    ")
+// EmitContainers satisfies OutputFormat so ECS tasks can be emitted
+func (t Task) EmitContainers(input *transform.PodData) ([]byte, error) {
+	return json.MarshalIndent(emitTask(input), "", "    ")
 }
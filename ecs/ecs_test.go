@@ -1,8 +1,15 @@
 package ecs
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
 	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
 )
 
 func TestIngestContainers(t *testing.T) {
@@ -38,3 +45,190 @@ func TestEmitContainers(t *testing.T) {
 	}
 
 }
+
+func TestHealthChecks(t *testing.T) {
+	f, err := os.Open("./test_fixtures/task.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := Task{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	db := (*bp.Containers)[0]
+	assert.Equal(t, []*transform.HealthCheck{{
+		Exec:             "pg_isready -U postgres",
+		ExecForm:         true,
+		Interval:         10,
+		Timeout:          5,
+		StartPeriod:      30,
+		FailureThreshold: 3,
+	}}, db.HealthChecks)
+
+	container := EmitContainer(db, map[string]string{})
+	assert.Equal(t, &HealthCheck{
+		Command:     []string{"CMD", "pg_isready", "-U", "postgres"},
+		Interval:    10,
+		Timeout:     5,
+		Retries:     3,
+		StartPeriod: 30,
+	}, container.HealthCheck)
+}
+
+func TestSecrets(t *testing.T) {
+	body := `services:
+  db:
+    image: postgres
+    secrets:
+    - db_password
+    - source: tls_key
+      target: /etc/ssl/server.key
+      mode: 0400
+secrets:
+  db_password:
+    file: ./db_password.txt
+  tls_key:
+    file: ./server.key
+`
+	bp, err := compose.DockerCompose{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+	bp.Secrets["db_password"].ValueFrom = "arn:aws:ssm:us-east-1:123456789012:parameter/db_password"
+	bp.Secrets["tls_key"].ValueFrom = "arn:aws:secretsmanager:us-east-1:123456789012:secret:tls_key"
+
+	task := emitTask(bp)
+	assert.Equal(t, []Secret{
+		{Name: "db_password", ValueFrom: "arn:aws:ssm:us-east-1:123456789012:parameter/db_password"},
+		{Name: "tls_key", ValueFrom: "arn:aws:secretsmanager:us-east-1:123456789012:secret:tls_key"},
+	}, (*task.ContainerDefinitions)[0].Secrets)
+
+	(*bp.Containers)[0].Secrets[1].Target = "tls.key"
+	task = emitTask(bp)
+	assert.Equal(t, "tls_key", (*task.ContainerDefinitions)[0].Secrets[1].Name)
+	assert.Equal(t, "_1password", envName("1password"))
+
+	delete(bp.Secrets, "tls_key")
+	task = emitTask(bp)
+	assert.Len(t, (*task.ContainerDefinitions)[0].Secrets, 1)
+
+	f, err := os.Open("./test_fixtures/task.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+	bp, err = Task{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+	assert.Equal(t, []transform.Secret{{Source: "POSTGRES_PASSWORD"}}, (*bp.Containers)[0].Secrets)
+	assert.Equal(t, map[string]*transform.SecretSource{
+		"POSTGRES_PASSWORD": {ValueFrom: "arn:aws:ssm:us-east-1:123456789012:parameter/pythonapp/db_password"},
+	}, bp.Secrets)
+}
+
+func TestLinuxParameters(t *testing.T) {
+	container := transform.Container{
+		Name:        "web",
+		CapAdd:      []string{"cap_net_admin"},
+		CapDrop:     []string{"ALL"},
+		Devices:     []transform.Device{{Host: "/dev/ttyUSB0", Container: "/dev/ttyS0", Permissions: "rw"}},
+		Init:        true,
+		ReadOnly:    true,
+		SecurityOpt: []string{"label=disable", "no-new-privileges:true", "seccomp=unconfined"},
+		ShmSize:     100 << 20,
+		Tmpfs: []transform.Tmpfs{
+			{Container: "/run", Size: 1 << 20, Options: []string{"noexec"}},
+			{Container: "/tmp"},
+		},
+	}
+
+	ecsContainer := EmitContainer(container, map[string]string{})
+	assert.Equal(t, &LinuxParameters{
+		Capabilities:       &KernelCapabilities{Add: []string{"NET_ADMIN"}, Drop: []string{"ALL"}},
+		Devices:            []Device{{HostPath: "/dev/ttyUSB0", ContainerPath: "/dev/ttyS0", Permissions: []string{"read", "write"}}},
+		InitProcessEnabled: true,
+		SharedMemorySize:   100,
+		Tmpfs: []Tmpfs{
+			{ContainerPath: "/run", Size: 1, MountOptions: []string{"noexec"}},
+			{ContainerPath: "/tmp", Size: defaultTmpfsSize},
+		},
+	}, ecsContainer.LinuxParameters)
+	assert.Equal(t, []string{"label:disable", "no-new-privileges"}, ecsContainer.DockerSecurityOptions)
+	assert.True(t, ecsContainer.ReadOnly)
+
+	ir := IngestContainer(ecsContainer, map[string]string{})
+	assert.Equal(t, []string{"NET_ADMIN"}, ir.CapAdd)
+	assert.Equal(t, container.Devices, ir.Devices)
+	assert.True(t, ir.Init)
+	assert.True(t, ir.ReadOnly)
+	assert.Equal(t, container.ShmSize, ir.ShmSize)
+	assert.Equal(t, []transform.Tmpfs{
+		{Container: "/run", Size: 1 << 20, Options: []string{"noexec"}},
+		{Container: "/tmp", Size: defaultTmpfsSize << 20},
+	}, ir.Tmpfs)
+}
+
+func TestUlimitsAndSystemControls(t *testing.T) {
+	container := transform.Container{
+		Name:    "db",
+		Sysctls: map[string]string{"net.ipv4.tcp_keepalive_time": "60", "net.core.somaxconn": "1024"},
+		Ulimits: []transform.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}},
+	}
+
+	ecsContainer := EmitContainer(container, map[string]string{})
+	assert.Equal(t, []SystemControl{
+		{Namespace: "net.core.somaxconn", Value: "1024"},
+		{Namespace: "net.ipv4.tcp_keepalive_time", Value: "60"},
+	}, ecsContainer.SystemControls)
+	assert.Equal(t, []Ulimit{{Name: "nofile", SoftLimit: 1024, HardLimit: 2048}}, ecsContainer.Ulimits)
+
+	ir := IngestContainer(ecsContainer, map[string]string{})
+	assert.Equal(t, container.Sysctls, ir.Sysctls)
+	assert.Equal(t, container.Ulimits, ir.Ulimits)
+}
+
+func emitFixture(t *testing.T, format transform.OutputFormat, fixture string) {
+	f, err := os.Open("./test_fixtures/task.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := Task{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := format.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitCloudFormation(t *testing.T) {
+	emitFixture(t, CloudFormation{}, "./test_fixtures/cloudformation.yaml")
+}
+
+func TestEmitTerraform(t *testing.T) {
+	emitFixture(t, Terraform{}, "./test_fixtures/task.tf")
+}
+
+func TestResourceNames(t *testing.T) {
+	assert.Equal(t, "MyWebAppTaskDefinition", logicalID("my-web_app"))
+	assert.Equal(t, "TaskDefinition", logicalID(""))
+	assert.Equal(t, "my-web_app", resourceName("my-web.app"))
+	assert.Equal(t, "task_1app", resourceName("1app"))
+	assert.Equal(t, "task", resourceName(""))
+}
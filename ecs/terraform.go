@@ -0,0 +1,90 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+var invalidResourceChars = regexp.MustCompile("[^a-zA-Z0-9_-]+")
+
+// resourceName derives a Terraform resource name from a pod's name
+func resourceName(name string) string {
+	name = strings.Trim(invalidResourceChars.ReplaceAllString(name, "_"), "_")
+	if len(name) == 0 {
+		return "task"
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		return "task_" + name
+	}
+	return name
+}
+
+// hclEscaper escapes HCL string escapes and template sequences
+var hclEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+
+// templateEscaper escapes template sequences in JSON, whose string escapes HCL shares
+var templateEscaper = strings.NewReplacer("${", "$${", "%{", "%%{")
+
+// quote returns an HCL string literal
+func quote(s string) string {
+	return `"` + hclEscaper.Replace(s) + `"`
+}
+
+const terraformTemplate = `resource "aws_ecs_task_definition" {{ quote .Name }} {
+  family = {{ quote .Task.Family }}
+{{- with .Task.NetworkMode }}
+  network_mode = {{ quote . }}
+{{- end }}
+  container_definitions = jsonencode({{ .ContainerDefinitions }})
+{{- range .Task.Volumes }}
+
+  volume {
+    name = {{ quote .Name }}
+{{- with .Host.SourcePath }}
+    host_path = {{ quote . }}
+{{- end }}
+  }
+{{- end }}
+}
+`
+
+// Terraform represents a Terraform aws_ecs_task_definition resource. It
+// implements OutputFormat
+type Terraform struct{}
+
+// EmitContainers satisfies OutputFormat so Terraform resources can be emitted
+func (tf Terraform) EmitContainers(input *transform.PodData) ([]byte, error) {
+	task := emitTask(input)
+	name := resourceName(input.Name)
+	if len(task.Family) == 0 {
+		task.Family = name
+	}
+
+	// JSON is valid HCL, so the container definitions are passed to jsonencode as is
+	containers, err := json.MarshalIndent(task.ContainerDefinitions, "  ", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	funcMap := template.FuncMap{
+		"quote": quote,
+	}
+
+	t := template.Must(template.New("resource").Funcs(funcMap).Parse(terraformTemplate))
+
+	var buffer bytes.Buffer
+	err = t.Execute(&buffer, map[string]interface{}{
+		"Name":                 name,
+		"Task":                 task,
+		"ContainerDefinitions": templateEscaper.Replace(string(containers)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
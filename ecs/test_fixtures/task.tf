@@ -0,0 +1,244 @@
+resource "aws_ecs_task_definition" "pythonapp" {
+  family = "pythonapp"
+  container_definitions = jsonencode([
+    {
+      "cpu": 200,
+      "essential": true,
+      "healthCheck": {
+        "command": [
+          "CMD",
+          "pg_isready",
+          "-U",
+          "postgres"
+        ],
+        "interval": 10,
+        "timeout": 5,
+        "retries": 3,
+        "startPeriod": 30
+      },
+      "image": "postgres:9.3",
+      "memory": 2048,
+      "name": "db",
+      "secrets": [
+        {
+          "name": "POSTGRES_PASSWORD",
+          "valueFrom": "arn:aws:ssm:us-east-1:123456789012:parameter/pythonapp/db_password"
+        }
+      ]
+    },
+    {
+      "cpu": 200,
+      "essential": true,
+      "image": "consul",
+      "memory": 1024,
+      "name": "dns",
+      "portMappings": [
+        {
+          "hostPort": 53,
+          "containerPort": 53,
+          "protocol": "udp"
+        }
+      ]
+    },
+    {
+      "cpu": 200,
+      "essential": true,
+      "image": "me/mylogs",
+      "memory": 4,
+      "name": "logs",
+      "mountPoints": [
+        {
+          "sourceVolume": "empty",
+          "containerPath": "/usr/local/apache2/htdocs/empty_volume"
+        },
+        {
+          "sourceVolume": "etc",
+          "containerPath": "/usr/local/apache2/htdocs/host_etc"
+        },
+        {
+          "sourceVolume": "var-log",
+          "containerPath": "/var/log2/"
+        }
+      ],
+      "volumesFrom": [
+        {
+          "sourceContainer": "web",
+          "readOnly": true
+        },
+        {
+          "sourceContainer": "web2"
+        }
+      ]
+    },
+    {
+      "cpu": 200,
+      "entryPoint": [
+        "redis"
+      ],
+      "essential": true,
+      "image": "redis:latest",
+      "dockerLabels": {
+        "com.example.emptyvalue": "",
+        "com.example.name": "web"
+      },
+      "logConfiguration": {
+        "logDriver": "gelf",
+        "options": {
+          "gelf-address": "udp://127.0.0.1:12900",
+          "tag": "redis"
+        }
+      },
+      "memory": 64,
+      "name": "redis"
+    },
+    {
+      "command": [
+        "uwsgi",
+        "--json",
+        "uwsgi.json"
+      ],
+      "cpu": 200,
+      "environment": [
+        {
+          "name": "AWS_ACCESS_KEY_ID",
+          "value": "AAAAAAAAAAAAAAAAAAAA"
+        },
+        {
+          "name": "AWS_EC2_REGION",
+          "value": "us-east-1"
+        },
+        {
+          "name": "AWS_SECRET_ACCESS_KEY",
+          "value": "1111111111111111111111111111111111111111"
+        },
+        {
+          "name": "BROKER_URL",
+          "value": "redis://redis:6379/0"
+        },
+        {
+          "name": "DB_HOST",
+          "value": "db"
+        },
+        {
+          "name": "DB_NAME",
+          "value": "postgres"
+        },
+        {
+          "name": "DB_PASS",
+          "value": "postgres"
+        },
+        {
+          "name": "DB_USER",
+          "value": "postgres"
+        }
+      ],
+      "essential": true,
+      "image": "me/myapp",
+      "links": [
+        "db",
+        "redis"
+      ],
+      "memory": 64,
+      "name": "web",
+      "portMappings": [
+        {
+          "hostPort": 8000,
+          "containerPort": 8000
+        },
+        {
+          "hostPort": 8000,
+          "containerPort": 8000
+        },
+        {
+          "hostPort": 8001,
+          "containerPort": 8001
+        },
+        {
+          "hostPort": 8002,
+          "containerPort": 8002
+        },
+        {
+          "containerPort": 8003
+        }
+      ]
+    },
+    {
+      "command": [
+        "--json",
+        "uwsgi.json"
+      ],
+      "cpu": 200,
+      "entryPoint": [
+        "uwsgi"
+      ],
+      "essential": true,
+      "image": "me/myapp",
+      "memory": 4,
+      "name": "web2"
+    },
+    {
+      "command": [
+        "celery",
+        "worker"
+      ],
+      "cpu": 200,
+      "environment": [
+        {
+          "name": "AWS_ACCESS_KEY_ID",
+          "value": "AAAAAAAAAAAAAAAAAAAA"
+        },
+        {
+          "name": "AWS_EC2_REGION",
+          "value": "us-east-1"
+        },
+        {
+          "name": "AWS_SECRET_ACCESS_KEY",
+          "value": "1111111111111111111111111111111111111111"
+        },
+        {
+          "name": "BROKER_URL",
+          "value": "redis://redis:6379/0"
+        },
+        {
+          "name": "DB_HOST",
+          "value": "db"
+        },
+        {
+          "name": "DB_NAME",
+          "value": "postgres"
+        },
+        {
+          "name": "DB_PAS",
+          "value": "postgres"
+        },
+        {
+          "name": "DB_USER",
+          "value": "postgres"
+        }
+      ],
+      "essential": true,
+      "image": "me/myapp",
+      "links": [
+        "db",
+        "redis",
+        "web"
+      ],
+      "memory": 64,
+      "name": "worker"
+    }
+  ])
+
+  volume {
+    name = "empty"
+  }
+
+  volume {
+    name = "etc"
+    host_path = "/etc"
+  }
+
+  volume {
+    name = "var-log"
+    host_path = "/var/log"
+  }
+}
@@ -0,0 +1,2 @@
+// Package kubernetes is for ingesting and emitting Kubernetes manifests
+package kubernetes
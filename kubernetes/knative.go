@@ -0,0 +1,158 @@
+package kubernetes
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+	"gopkg.in/yaml.v2"
+)
+
+// KnativeServiceSpec is a type for storing a Knative Service specification
+type KnativeServiceSpec struct {
+	Template PodTemplateSpec `yaml:"template"`
+}
+
+// KnativeService represents a Knative Serving Service, as run by Cloud Run.
+// It implements OutputFormat
+type KnativeService struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   ObjectMeta         `yaml:"metadata"`
+	Spec       KnativeServiceSpec `yaml:"spec"`
+}
+
+// containerPorts returns the distinct container ports a container exposes
+func containerPorts(container transform.Container) []int {
+	ports := []int{}
+	seen := map[int]bool{}
+	if container.PortMappings != nil {
+		for _, pm := range *container.PortMappings {
+			if pm.ContainerPort > 0 && !seen[pm.ContainerPort] {
+				seen[pm.ContainerPort] = true
+				ports = append(ports, pm.ContainerPort)
+			}
+		}
+	}
+	return ports
+}
+
+// validateKnative returns an error listing everything in the pod that a
+// Knative Service cannot express
+func validateKnative(input *transform.PodData) error {
+	problems := []string{}
+	if input.HostNetwork {
+		problems = append(problems, "the pod uses host networking")
+	}
+	if input.HostPID {
+		problems = append(problems, "the pod uses the host PID namespace")
+	}
+
+	ingress := []string{}
+	for _, container := range *input.Containers {
+		if container.Privileged {
+			problems = append(problems, "container "+container.Name+" is privileged")
+		}
+		if container.NetworkMode == "host" {
+			problems = append(problems, "container "+container.Name+" uses host networking")
+		}
+		if container.Pid == "host" {
+			problems = append(problems, "container "+container.Name+" uses the host PID namespace")
+		}
+		if container.Volumes != nil {
+			for _, volume := range *container.Volumes {
+				if len(volume.Host) > 0 {
+					problems = append(problems, "container "+container.Name+" mounts host path "+volume.Host)
+				}
+			}
+		}
+		if ports := containerPorts(container); len(ports) > 0 {
+			ingress = append(ingress, container.Name)
+			if len(ports) > 1 {
+				problems = append(problems, "container "+container.Name+" exposes multiple ports")
+			}
+		}
+	}
+	if len(ingress) > 1 {
+		problems = append(problems, "containers "+strings.Join(ingress, ", ")+" all expose ports, only one can receive requests")
+	}
+	if len(ingress) == 0 && len(*input.Containers) > 1 {
+		problems = append(problems, "no container exposes a port to receive requests")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("pod cannot be expressed as a Knative Service: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// emitKnativeContainer adapts a pod spec container to Knative, which only
+// accepts a single container port and sets resource limits rather than requests
+func emitKnativeContainer(c *Container, container transform.Container) {
+	c.Ports = nil
+	if ports := containerPorts(container); len(ports) == 1 {
+		c.Ports = []ContainerPort{{ContainerPort: ports[0]}}
+		for _, pm := range *container.PortMappings {
+			if pm.HostPort > 0 {
+				log.Printf("Ignoring host port %d for container %s, Knative routes requests to the container port", pm.HostPort, container.Name)
+			}
+			if strings.ToLower(pm.Name) == "h2c" {
+				c.Ports[0].Name = "h2c"
+			}
+		}
+	}
+
+	c.Resources = nil
+	if container.CPU > 0 || container.Memory > 0 {
+		c.Resources = &ResourceRequirements{Limits: map[string]string{}}
+		if container.CPU > 0 {
+			// CPU shares are out of 1024, Kubernetes measures in millicores.
+			// Knative rejects a limit of 0, so a share or two is rounded up
+			millicores := container.CPU * 1000 / 1024
+			if millicores == 0 {
+				millicores = 1
+			}
+			c.Resources.Limits["cpu"] = strconv.Itoa(millicores) + "m"
+		}
+		if container.Memory > 0 {
+			c.Resources.Limits["memory"] = formatMemory(container.Memory)
+		}
+	}
+}
+
+// EmitContainers satisfies OutputFormat so Knative Services can be emitted.
+// The container exposing a port receives requests, and the rest run as sidecars
+func (k KnativeService) EmitContainers(input *transform.PodData) ([]byte, error) {
+	if err := validateKnative(input); err != nil {
+		return nil, err
+	}
+
+	name := podName(input)
+	spec, annotations := emitPodSpec(input)
+	for i, container := range *input.Containers {
+		emitKnativeContainer(&spec.Containers[i], container)
+	}
+	if len(spec.Hostname) > 0 || spec.DNSConfig != nil {
+		log.Printf("Ignoring hostname and DNS settings for %s, Knative manages them", name)
+		spec.Hostname = ""
+		spec.DNSConfig = nil
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+
+	output := &KnativeService{
+		APIVersion: "serving.knative.dev/v1",
+		Kind:       "Service",
+		Metadata:   ObjectMeta{Name: name, Labels: input.GlobalLabels},
+		Spec: KnativeServiceSpec{
+			Template: PodTemplateSpec{
+				Metadata: ObjectMeta{Annotations: annotations},
+				Spec:     spec,
+			},
+		},
+	}
+	return yaml.Marshal(output)
+}
@@ -0,0 +1,644 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+	"gopkg.in/yaml.v2"
+)
+
+var invalidNameChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// sanitizeName converts a string into a DNS-1123 label suitable for Kubernetes
+// object, container, and volume names
+func sanitizeName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > 63 {
+		name = strings.Trim(name[:63], "-")
+	}
+	return name
+}
+
+// podName returns the name to use for the pod's owning object, falling back to
+// the first container's name if the pod has none
+func podName(input *transform.PodData) string {
+	name := sanitizeName(input.Name)
+	if len(name) == 0 && input.Containers != nil && len(*input.Containers) > 0 {
+		name = sanitizeName((*input.Containers)[0].Name)
+	}
+	return name
+}
+
+// podLabels returns the labels used to select the pod, defaulting to an app
+// label when the pod has no global labels
+func podLabels(input *transform.PodData) map[string]string {
+	if len(input.GlobalLabels) > 0 {
+		return input.GlobalLabels
+	}
+	return map[string]string{"app": podName(input)}
+}
+
+// ObjectMeta is a type for storing Kubernetes object metadata
+type ObjectMeta struct {
+	Name        string            `yaml:"name,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// LabelSelector is a type for storing a Kubernetes label selector
+type LabelSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels,omitempty"`
+}
+
+// EnvVar is a type for storing Kubernetes environment variables
+type EnvVar struct {
+	Name      string      `yaml:"name"`
+	Value     string      `yaml:"value"`
+	ValueFrom interface{} `yaml:"valueFrom,omitempty"`
+}
+
+// EnvVars is a composite type for slices of EnvVar
+type EnvVars []EnvVar
+
+func (env EnvVars) Len() int      { return len(env) }
+func (env EnvVars) Swap(i, j int) { env[i], env[j] = env[j], env[i] }
+func (env EnvVars) Less(i, j int) bool {
+	return strings.Compare(env[i].Name, env[j].Name) < 0
+}
+
+func (c Container) ingestEnvironment() map[string]string {
+	if len(c.Env) > 0 {
+		env := map[string]string{}
+		for _, envVar := range c.Env {
+			if envVar.ValueFrom != nil {
+				log.Printf("Ignoring environment variable %s for container %s: valueFrom is not supported", envVar.Name, c.Name)
+				continue
+			}
+			env[envVar.Name] = envVar.Value
+		}
+		return env
+	}
+	return nil
+}
+
+func (c *Container) emitEnvironment(env map[string]string) {
+	if len(env) > 0 {
+		envs := EnvVars{}
+		for n, v := range env {
+			envs = append(envs, EnvVar{Name: n, Value: v})
+		}
+		sort.Sort(envs)
+		c.Env = envs
+	}
+}
+
+// ContainerPort is a type for storing Kubernetes container port information
+type ContainerPort struct {
+	Name          string `yaml:"name,omitempty"`
+	HostIP        string `yaml:"hostIP,omitempty"`
+	HostPort      int    `yaml:"hostPort,omitempty"`
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol,omitempty"`
+}
+
+func (c Container) ingestPortMappings() *transform.PortMappings {
+	if len(c.Ports) > 0 {
+		response := transform.PortMappings{}
+		for _, port := range c.Ports {
+			protocol := strings.ToLower(port.Protocol)
+			if len(protocol) == 0 {
+				protocol = "tcp"
+			}
+			response = append(response, transform.PortMapping{
+				HostIP:        port.HostIP,
+				HostPort:      port.HostPort,
+				ContainerPort: port.ContainerPort,
+				Protocol:      protocol,
+				Name:          port.Name,
+			})
+		}
+		return &response
+	}
+	return nil
+}
+
+func (c *Container) emitPortMappings(in *transform.PortMappings) {
+	if in == nil {
+		return
+	}
+	seen := map[ContainerPort]bool{}
+	for _, pm := range *in {
+		port := ContainerPort{
+			Name:          strings.ToLower(pm.Name),
+			HostIP:        pm.HostIP,
+			HostPort:      pm.HostPort,
+			ContainerPort: pm.ContainerPort,
+			Protocol:      strings.ToUpper(pm.Protocol),
+		}
+		if seen[port] {
+			continue
+		}
+		seen[port] = true
+		c.Ports = append(c.Ports, port)
+	}
+}
+
+// ResourceRequirements is a type for storing Kubernetes resource requests and limits
+type ResourceRequirements struct {
+	Limits   map[string]string `yaml:"limits,omitempty"`
+	Requests map[string]string `yaml:"requests,omitempty"`
+}
+
+var quantitySuffixes = map[string]float64{
+	"m":  1e-3,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// parseQuantity converts a Kubernetes quantity such as "500m" or "128Mi" into
+// its numeric value
+func parseQuantity(quantity string) (float64, error) {
+	number := strings.TrimRight(quantity, "kmKMGTPEi")
+	multiplier := 1.0
+	if suffix := quantity[len(number):]; len(suffix) > 0 {
+		m, ok := quantitySuffixes[suffix]
+		if !ok {
+			return 0, fmt.Errorf("invalid quantity suffix in %s", quantity)
+		}
+		multiplier = m
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, err
+	}
+	return value * multiplier, nil
+}
+
+// formatMemory converts bytes to a Kubernetes quantity, using the largest
+// binary suffix that represents it exactly
+func formatMemory(mem int) string {
+	suffixes := []struct {
+		suffix string
+		shift  uint
+	}{{"Gi", 30}, {"Mi", 20}, {"Ki", 10}}
+	for _, s := range suffixes {
+		if mem%(1<<s.shift) == 0 {
+			return strconv.Itoa(mem>>s.shift) + s.suffix
+		}
+	}
+	return strconv.Itoa(mem)
+}
+
+// resource returns the named resource's limit, falling back to its request
+func (r ResourceRequirements) resource(name string) (float64, error) {
+	quantity, ok := r.Limits[name]
+	if !ok {
+		quantity, ok = r.Requests[name]
+	}
+	if !ok {
+		return 0, nil
+	}
+	return parseQuantity(quantity)
+}
+
+func (c Container) ingestResources() (cpu int, mem int, err error) {
+	if c.Resources == nil {
+		return 0, 0, nil
+	}
+	cores, err := c.Resources.resource("cpu")
+	if err != nil {
+		return 0, 0, err
+	}
+	bytes, err := c.Resources.resource("memory")
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(cores * 1024), int(bytes), nil
+}
+
+func (c *Container) emitResources(cpu, mem int) {
+	if cpu == 0 && mem == 0 {
+		return
+	}
+	c.Resources = &ResourceRequirements{}
+	if cpu > 0 {
+		// CPU shares are out of 1024, Kubernetes measures in millicores
+		c.Resources.Requests = map[string]string{"cpu": strconv.Itoa(cpu*1000/1024) + "m"}
+	}
+	if mem > 0 {
+		c.Resources.Limits = map[string]string{"memory": formatMemory(mem)}
+	}
+}
+
+// SecurityContext is a type for storing a Kubernetes container security context
+type SecurityContext struct {
+	Privileged bool   `yaml:"privileged,omitempty"`
+	RunAsUser  *int64 `yaml:"runAsUser,omitempty"`
+	RunAsGroup *int64 `yaml:"runAsGroup,omitempty"`
+}
+
+func (c Container) ingestUser() string {
+	if c.SecurityContext == nil || c.SecurityContext.RunAsUser == nil {
+		return ""
+	}
+	user := strconv.FormatInt(*c.SecurityContext.RunAsUser, 10)
+	if c.SecurityContext.RunAsGroup != nil {
+		user += ":" + strconv.FormatInt(*c.SecurityContext.RunAsGroup, 10)
+	}
+	return user
+}
+
+func (c *Container) emitSecurityContext(privileged bool, user string) {
+	sc := SecurityContext{Privileged: privileged}
+	if len(user) > 0 {
+		parts := strings.SplitN(user, ":", 2)
+		if parts[0] == "root" {
+			parts[0] = "0"
+		}
+		uid, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			log.Printf("Kubernetes requires a numeric user, ignoring user %s for container %s", user, c.Name)
+		} else {
+			sc.RunAsUser = &uid
+			if len(parts) > 1 {
+				if gid, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+					sc.RunAsGroup = &gid
+				}
+			}
+		}
+	}
+	if sc.Privileged || sc.RunAsUser != nil {
+		c.SecurityContext = &sc
+	}
+}
+
+// VolumeMount is a type for storing Kubernetes container mount information
+type VolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+// HostPathVolumeSource is a type for storing a Kubernetes hostPath volume
+type HostPathVolumeSource struct {
+	Path string `yaml:"path"`
+}
+
+// EmptyDirVolumeSource is a type for storing a Kubernetes emptyDir volume
+type EmptyDirVolumeSource struct{}
+
+// Volume is a type for storing a pod-level volume
+type Volume struct {
+	Name     string                `yaml:"name"`
+	HostPath *HostPathVolumeSource `yaml:"hostPath,omitempty"`
+	EmptyDir *EmptyDirVolumeSource `yaml:"emptyDir,omitempty"`
+}
+
+// Volumes is a composite type for slices of Volume
+type Volumes []Volume
+
+func (vs Volumes) Len() int      { return len(vs) }
+func (vs Volumes) Swap(i, j int) { vs[i], vs[j] = vs[j], vs[i] }
+func (vs Volumes) Less(i, j int) bool {
+	return strings.Compare(vs[i].Name, vs[j].Name) < 0
+}
+
+func (c Container) ingestVolumes(volumes map[string]Volume) *transform.IntermediateVolumes {
+	if len(c.VolumeMounts) > 0 {
+		response := transform.IntermediateVolumes{}
+		for _, mount := range c.VolumeMounts {
+			iv := transform.IntermediateVolume{
+				Container: mount.MountPath,
+				ReadOnly:  mount.ReadOnly,
+			}
+			if vol, ok := volumes[mount.Name]; ok && vol.HostPath != nil {
+				iv.Host = vol.HostPath.Path
+			} else {
+				iv.SourceVolume = mount.Name
+			}
+			response = append(response, iv)
+		}
+		return &response
+	}
+	return nil
+}
+
+// emitVolumes adds volume mounts to the container and returns the pod volumes
+// they reference, keyed by volume name
+func (c *Container) emitVolumes(vols *transform.IntermediateVolumes) map[string]Volume {
+	response := map[string]Volume{}
+	if vols == nil {
+		return response
+	}
+	for i, volume := range *vols {
+		host := volume.Host
+		if len(host) > 0 && !strings.HasPrefix(host, "/") {
+			if len(volume.NamedVolume()) == 0 {
+				log.Printf("Kubernetes requires absolute host paths, mounting an empty directory instead of %s for container %s", host, c.Name)
+			}
+			host = ""
+		}
+		name := sanitizeName(host)
+		if len(host) == 0 {
+			name = sanitizeName(volume.NamedVolume())
+			if len(name) == 0 {
+				name = sanitizeName(c.Name + "-" + strconv.Itoa(i))
+			}
+			response[name] = Volume{Name: name, EmptyDir: &EmptyDirVolumeSource{}}
+		} else {
+			if len(name) == 0 {
+				name = sanitizeName(c.Name + "-host-" + strconv.Itoa(i))
+			}
+			response[name] = Volume{Name: name, HostPath: &HostPathVolumeSource{Path: host}}
+		}
+		c.VolumeMounts = append(c.VolumeMounts, VolumeMount{
+			Name:      name,
+			MountPath: volume.Container,
+			ReadOnly:  volume.ReadOnly,
+		})
+	}
+	return response
+}
+
+// Container is a type for storing Kubernetes container information
+type Container struct {
+	Name            string                `yaml:"name"`
+	Image           string                `yaml:"image"`
+	Command         []string              `yaml:"command,omitempty"`
+	Args            []string              `yaml:"args,omitempty"`
+	WorkingDir      string                `yaml:"workingDir,omitempty"`
+	Ports           []ContainerPort       `yaml:"ports,omitempty"`
+	Env             EnvVars               `yaml:"env,omitempty"`
+	Resources       *ResourceRequirements `yaml:"resources,omitempty"`
+	VolumeMounts    []VolumeMount         `yaml:"volumeMounts,omitempty"`
+	SecurityContext *SecurityContext      `yaml:"securityContext,omitempty"`
+}
+
+// PodDNSConfig is a type for storing pod DNS settings
+type PodDNSConfig struct {
+	Nameservers []string `yaml:"nameservers,omitempty"`
+	Searches    []string `yaml:"searches,omitempty"`
+}
+
+// PodSpec is a type for storing a Kubernetes pod specification
+type PodSpec struct {
+	Hostname    string        `yaml:"hostname,omitempty"`
+	HostNetwork bool          `yaml:"hostNetwork,omitempty"`
+	HostPID     bool          `yaml:"hostPID,omitempty"`
+	DNSConfig   *PodDNSConfig `yaml:"dnsConfig,omitempty"`
+	Containers  []Container   `yaml:"containers"`
+	Volumes     Volumes       `yaml:"volumes,omitempty"`
+}
+
+// PodTemplateSpec is a type for storing a Kubernetes pod template
+type PodTemplateSpec struct {
+	Metadata ObjectMeta `yaml:"metadata"`
+	Spec     PodSpec    `yaml:"spec"`
+}
+
+// DeploymentSpec is a type for storing a Kubernetes Deployment specification
+type DeploymentSpec struct {
+	Replicas int             `yaml:"replicas,omitempty"`
+	Selector *LabelSelector  `yaml:"selector,omitempty"`
+	Template PodTemplateSpec `yaml:"template"`
+}
+
+// Deployment represents a Kubernetes Deployment. It implements InputFormat and OutputFormat.
+// StatefulSets and DaemonSets share its shape, so they are ingested as Deployments
+type Deployment struct {
+	APIVersion string         `yaml:"apiVersion"`
+	Kind       string         `yaml:"kind"`
+	Metadata   ObjectMeta     `yaml:"metadata"`
+	Spec       DeploymentSpec `yaml:"spec"`
+}
+
+// Pod represents a bare Kubernetes Pod
+type Pod struct {
+	APIVersion string     `yaml:"apiVersion"`
+	Kind       string     `yaml:"kind"`
+	Metadata   ObjectMeta `yaml:"metadata"`
+	Spec       PodSpec    `yaml:"spec"`
+}
+
+// manifestKind is used to determine a manifest's kind before fully decoding it
+type manifestKind struct {
+	Kind string `yaml:"kind"`
+}
+
+var documentSeparator = regexp.MustCompile("(?m)^---.*$")
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// ingestPodSpec converts a pod spec and its metadata into the intermediate pod
+func ingestPodSpec(meta ObjectMeta, spec PodSpec) (*transform.PodData, error) {
+	outputPod := transform.PodData{
+		GlobalLabels: meta.Labels,
+		HostNetwork:  spec.HostNetwork,
+		HostPID:      spec.HostPID,
+	}
+	containers := transform.Containers{}
+
+	volumes := map[string]Volume{}
+	for _, vol := range spec.Volumes {
+		volumes[vol.Name] = vol
+	}
+
+	for _, container := range spec.Containers {
+		ir := transform.Container{}
+		ir.Command = container.Args
+		cpu, mem, err := container.ingestResources()
+		if err != nil {
+			return nil, err
+		}
+		ir.CPU = cpu
+		if spec.DNSConfig != nil {
+			ir.DNS = spec.DNSConfig.Nameservers
+			ir.Domain = spec.DNSConfig.Searches
+		}
+		ir.Entrypoint = container.Command
+		ir.Environment = container.ingestEnvironment()
+		ir.Hostname = spec.Hostname
+		ir.Image = container.Image
+		if len(meta.Annotations) > 0 {
+			ir.Labels = meta.Annotations
+		}
+		ir.Memory = mem
+		ir.Name = container.Name
+		ir.PortMappings = container.ingestPortMappings()
+		if container.SecurityContext != nil {
+			ir.Privileged = container.SecurityContext.Privileged
+		}
+		ir.User = container.ingestUser()
+		ir.Volumes = container.ingestVolumes(volumes)
+		ir.WorkDir = container.WorkingDir
+		containers = append(containers, ir)
+	}
+	sort.Sort(containers)
+	outputPod.Containers = &containers
+	return &outputPod, nil
+}
+
+// emitPodSpec converts the intermediate pod into a pod spec and the
+// annotations carrying each container's labels
+func emitPodSpec(input *transform.PodData) (PodSpec, map[string]string) {
+	spec := PodSpec{
+		HostNetwork: input.HostNetwork,
+		HostPID:     input.HostPID,
+	}
+	annotations := map[string]string{}
+	volumesMap := map[string]Volume{}
+	dns := PodDNSConfig{}
+
+	for _, container := range *input.Containers {
+		k8sContainer := Container{}
+		k8sContainer.Name = sanitizeName(container.Name)
+		k8sContainer.Image = container.Image
+		k8sContainer.Command = container.Entrypoint
+		k8sContainer.Args = container.CommandArgs()
+		k8sContainer.WorkingDir = container.WorkDir
+		k8sContainer.emitPortMappings(container.PortMappings)
+		k8sContainer.emitEnvironment(container.Environment)
+		k8sContainer.emitResources(container.CPU, container.Memory)
+		for k, v := range k8sContainer.emitVolumes(container.Volumes) {
+			volumesMap[k] = v
+		}
+		k8sContainer.emitSecurityContext(container.Privileged, container.User)
+
+		// Kubernetes has no per-container labels, and label values are too
+		// restrictive for most docker labels, so they are kept as annotations
+		for k, v := range container.Labels {
+			annotations[k] = v
+		}
+		if len(spec.Hostname) == 0 {
+			spec.Hostname = container.Hostname
+		}
+		if container.NetworkMode == "host" {
+			spec.HostNetwork = true
+		}
+		if container.Pid == "host" {
+			spec.HostPID = true
+		}
+		dns.Nameservers = appendUnique(dns.Nameservers, container.DNS...)
+		dns.Searches = appendUnique(dns.Searches, container.Domain...)
+		spec.Containers = append(spec.Containers, k8sContainer)
+	}
+	for _, v := range volumesMap {
+		spec.Volumes = append(spec.Volumes, v)
+	}
+	sort.Sort(spec.Volumes)
+	if len(dns.Nameservers) > 0 || len(dns.Searches) > 0 {
+		spec.DNSConfig = &dns
+	}
+	return spec, annotations
+}
+
+// IngestContainers satisfies InputFormat so Kubernetes workloads can be ingested.
+// The first Deployment, StatefulSet, DaemonSet, or Pod in the manifest is used
+func (d Deployment) IngestContainers(input io.ReadCloser) (*transform.PodData, error) {
+
+	body, err := ioutil.ReadAll(input)
+	defer input.Close()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	for _, document := range documentSeparator.Split(string(body), -1) {
+		kind := manifestKind{}
+		err = yaml.Unmarshal([]byte(document), &kind)
+		if err != nil {
+			return nil, err
+		}
+
+		switch kind.Kind {
+		case "Deployment", "StatefulSet", "DaemonSet":
+			err = yaml.Unmarshal([]byte(document), &d)
+			if err != nil {
+				return nil, err
+			}
+			pod, err := ingestPodSpec(d.Spec.Template.Metadata, d.Spec.Template.Spec)
+			if err != nil {
+				return nil, err
+			}
+			pod.Name = d.Metadata.Name
+			pod.Replicas = d.Spec.Replicas
+			return pod, nil
+		case "Pod":
+			p := Pod{}
+			err = yaml.Unmarshal([]byte(document), &p)
+			if err != nil {
+				return nil, err
+			}
+			pod, err := ingestPodSpec(p.Metadata, p.Spec)
+			if err != nil {
+				return nil, err
+			}
+			pod.Name = p.Metadata.Name
+			return pod, nil
+		}
+	}
+	return nil, errors.New("no Deployment, StatefulSet, DaemonSet, or Pod found in manifest")
+}
+
+// EmitContainers satisfies OutputFormat so Kubernetes Deployments can be emitted
+func (d Deployment) EmitContainers(input *transform.PodData) ([]byte, error) {
+	name := podName(input)
+	labels := podLabels(input)
+	spec, annotations := emitPodSpec(input)
+
+	replicas := input.Replicas
+	if replicas == 0 {
+		for _, container := range *input.Containers {
+			if container.Replicas > replicas {
+				replicas = container.Replicas
+			}
+		}
+	}
+
+	output := &Deployment{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Metadata:   ObjectMeta{Name: name, Labels: labels},
+		Spec: DeploymentSpec{
+			Replicas: replicas,
+			Selector: &LabelSelector{MatchLabels: labels},
+			Template: PodTemplateSpec{
+				Metadata: ObjectMeta{Labels: labels, Annotations: annotations},
+				Spec:     spec,
+			},
+		},
+	}
+	return yaml.Marshal(output)
+}
@@ -0,0 +1,251 @@
+package kubernetes
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestIngestContainers(t *testing.T) {
+	d := Deployment{}
+
+	f, err := os.Open("./test_fixtures/deployment.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	_, err = d.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+}
+
+func TestIngestStatefulSet(t *testing.T) {
+	d := Deployment{}
+
+	f, err := os.Open("./test_fixtures/statefulset.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := d.IngestContainers(f)
+	if err != nil {
+		t.Fatalf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, "db", bp.Name)
+	assert.Equal(t, 3, bp.Replicas)
+	assert.True(t, bp.HostNetwork)
+	assert.True(t, bp.HostPID)
+	assert.Equal(t, map[string]string{"app": "db"}, bp.GlobalLabels)
+
+	container := (*bp.Containers)[0]
+	assert.Equal(t, []string{"-c", "max_connections=200"}, container.Command)
+	assert.Equal(t, 512, container.CPU)
+	assert.Equal(t, 1<<30, container.Memory)
+	assert.Equal(t, "999:999", container.User)
+	assert.Equal(t, "Accounting database", container.Labels["com.example.description"])
+	assert.Equal(t, "/var/lib/postgresql/data/pgdata", container.Environment["PGDATA"])
+	assert.Equal(t, transform.IntermediateVolumes{
+		{Container: "/var/lib/postgresql/data", SourceVolume: "data"},
+		{Host: "/etc/ssl", Container: "/etc/ssl", ReadOnly: true},
+	}, *container.Volumes)
+}
+
+func TestIngestPod(t *testing.T) {
+	d := Deployment{}
+
+	f, err := os.Open("./test_fixtures/pod.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := d.IngestContainers(f)
+	if err != nil {
+		t.Fatalf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, "static-web", bp.Name)
+	container := (*bp.Containers)[0]
+	assert.Equal(t, []string{"nginx"}, container.Entrypoint)
+	assert.True(t, container.Privileged)
+	assert.Equal(t, transform.PortMappings{
+		{ContainerPort: 80, Protocol: "tcp", Name: "web"},
+	}, *container.PortMappings)
+}
+
+func TestEmitContainers(t *testing.T) {
+	cf := compose.DockerCompose{}
+
+	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := cf.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := Deployment{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/deployment.yaml")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitService(t *testing.T) {
+	d := Deployment{}
+
+	f, err := os.Open("./test_fixtures/web.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := d.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := Service{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/service.yaml")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitServiceWithoutPorts(t *testing.T) {
+	bp := &transform.PodData{
+		Name:       "worker",
+		Containers: &transform.Containers{{Name: "worker", Image: "busybox"}},
+	}
+
+	_, err := Service{}.EmitContainers(bp)
+	assert.Error(t, err)
+}
+
+func TestEmitServicePortNames(t *testing.T) {
+	containers := &transform.Containers{
+		{Name: "web", PortMappings: &transform.PortMappings{
+			{Name: "http", ContainerPort: 80, Protocol: "tcp"},
+			{Name: "http-administration", ContainerPort: 8080, Protocol: "tcp"},
+		}},
+		{Name: "api", PortMappings: &transform.PortMappings{
+			{Name: "http", ContainerPort: 8000, Protocol: "tcp"},
+			{Name: "http", ContainerPort: 53, Protocol: "udp"},
+			{Name: "8443", ContainerPort: 8443, Protocol: "tcp"},
+		}},
+	}
+
+	s := &Service{}
+	httpPorts := s.emitPorts(containers)
+	names := []string{}
+	for _, port := range s.Spec.Ports {
+		names = append(names, port.Name)
+	}
+	assert.Equal(t, []string{"http", "http-administra", "http-2", "http-3", "tcp-8443"}, names)
+	assert.Equal(t, []httpPort{
+		{Name: "http", Original: "http"},
+		{Name: "http-administra", Original: "http-administration"},
+		{Name: "http-2", Original: "http"},
+	}, httpPorts)
+
+	paths := map[string]string{}
+	for _, path := range emitIngress("web", nil, httpPorts).Spec.Rules[0].HTTP.Paths {
+		paths[path.Path] = path.Backend.Service.Port.Name
+	}
+	assert.Equal(t, map[string]string{"/": "http", "/administration": "http-administra"}, paths)
+}
+
+func TestEmitKnativeService(t *testing.T) {
+	cf := compose.DockerCompose{}
+
+	f, err := os.Open("./test_fixtures/cloudrun.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := cf.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := KnativeService{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/knative.yaml")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitKnativeServiceUnsupported(t *testing.T) {
+	bp := &transform.PodData{
+		Containers: &transform.Containers{
+			{
+				Name:         "web",
+				Image:        "nginx",
+				Privileged:   true,
+				PortMappings: &transform.PortMappings{{ContainerPort: 80}, {ContainerPort: 443}},
+				Volumes:      &transform.IntermediateVolumes{{Host: "/etc/ssl", Container: "/etc/ssl"}},
+			},
+			{
+				Name:         "admin",
+				Image:        "admin",
+				NetworkMode:  "host",
+				PortMappings: &transform.PortMappings{{ContainerPort: 9000}},
+			},
+		},
+	}
+
+	_, err := KnativeService{}.EmitContainers(bp)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "container web is privileged")
+		assert.Contains(t, err.Error(), "container web mounts host path /etc/ssl")
+		assert.Contains(t, err.Error(), "container web exposes multiple ports")
+		assert.Contains(t, err.Error(), "container admin uses host networking")
+		assert.Contains(t, err.Error(), "containers web, admin all expose ports")
+	}
+}
+
+func TestEmitKnativeContainerCPU(t *testing.T) {
+	for shares, limit := range map[int]string{1: "1m", 2: "1m", 512: "500m", 1024: "1000m"} {
+		c := &Container{}
+		emitKnativeContainer(c, transform.Container{Name: "web", CPU: shares, PortMappings: &transform.PortMappings{{ContainerPort: 80}}})
+		assert.Equal(t, limit, c.Resources.Limits["cpu"], "%d shares", shares)
+	}
+}
@@ -0,0 +1,260 @@
+package kubernetes
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/micahhausler/container-tx/transform"
+	"gopkg.in/yaml.v2"
+)
+
+// ServicePort is a type for storing a Kubernetes Service port
+type ServicePort struct {
+	Name       string `yaml:"name"`
+	Protocol   string `yaml:"protocol"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+	NodePort   int    `yaml:"nodePort,omitempty"`
+}
+
+// ServiceSpec is a type for storing a Kubernetes Service specification
+type ServiceSpec struct {
+	Type     string            `yaml:"type"`
+	Selector map[string]string `yaml:"selector"`
+	Ports    []ServicePort     `yaml:"ports"`
+}
+
+// Service represents a Kubernetes Service, along with an Ingress for any HTTP
+// ports. It implements OutputFormat
+type Service struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   ObjectMeta  `yaml:"metadata"`
+	Spec       ServiceSpec `yaml:"spec"`
+}
+
+// ServiceBackendPort is a type for storing the Service port an Ingress routes to
+type ServiceBackendPort struct {
+	Name string `yaml:"name"`
+}
+
+// IngressServiceBackend is a type for storing the Service an Ingress routes to
+type IngressServiceBackend struct {
+	Name string             `yaml:"name"`
+	Port ServiceBackendPort `yaml:"port"`
+}
+
+// IngressBackend is a type for storing an Ingress backend
+type IngressBackend struct {
+	Service IngressServiceBackend `yaml:"service"`
+}
+
+// HTTPIngressPath is a type for storing an Ingress path rule
+type HTTPIngressPath struct {
+	Path     string         `yaml:"path"`
+	PathType string         `yaml:"pathType"`
+	Backend  IngressBackend `yaml:"backend"`
+}
+
+// HTTPIngressRuleValue is a type for storing an Ingress rule's HTTP paths
+type HTTPIngressRuleValue struct {
+	Paths []HTTPIngressPath `yaml:"paths"`
+}
+
+// IngressRule is a type for storing an Ingress rule
+type IngressRule struct {
+	HTTP HTTPIngressRuleValue `yaml:"http"`
+}
+
+// IngressSpec is a type for storing a Kubernetes Ingress specification
+type IngressSpec struct {
+	Rules []IngressRule `yaml:"rules"`
+}
+
+// Ingress represents a Kubernetes Ingress
+type Ingress struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   ObjectMeta  `yaml:"metadata"`
+	Spec       IngressSpec `yaml:"spec"`
+}
+
+// isHTTPPort reports whether a port is named as carrying HTTP traffic, following
+// the "http" or "http-<suffix>" port naming convention
+func isHTTPPort(name string) bool {
+	name = strings.ToLower(name)
+	return name == "http" || strings.HasPrefix(name, "http-")
+}
+
+// servicePortName returns a port's name, generating one from the protocol
+// and port when the mapping has none, or one without a letter, which
+// Kubernetes rejects
+func servicePortName(pm transform.PortMapping) string {
+	if name := sanitizeName(pm.Name); strings.IndexFunc(name, unicode.IsLetter) >= 0 {
+		return name
+	}
+	protocol := strings.ToLower(pm.Protocol)
+	if len(protocol) == 0 {
+		protocol = "tcp"
+	}
+	return protocol + "-" + strconv.Itoa(pm.ContainerPort)
+}
+
+// maxPortName is the longest port name Kubernetes accepts
+const maxPortName = 15
+
+// truncatePortName shortens a port name to at most length characters
+func truncatePortName(name string, length int) string {
+	if len(name) > length {
+		name = strings.Trim(name[:length], "-")
+	}
+	return name
+}
+
+// uniquePortName shortens a port name to maxPortName and numbers it if another
+// port already has the name, since Service port names must be unique
+func uniquePortName(name string, taken map[string]bool) string {
+	unique := truncatePortName(name, maxPortName)
+	for i := 2; taken[unique]; i++ {
+		suffix := "-" + strconv.Itoa(i)
+		unique = truncatePortName(name, maxPortName-len(suffix)) + suffix
+	}
+	taken[unique] = true
+	return unique
+}
+
+// httpPort is a Service port carrying HTTP. Its Ingress path comes from the
+// name in the input, before it was shortened or numbered
+type httpPort struct {
+	Name     string
+	Original string
+}
+
+// emitPorts converts every container's port mappings into Service ports and
+// returns the TCP ports carrying HTTP
+func (s *Service) emitPorts(containers *transform.Containers) []httpPort {
+	httpPorts := []httpPort{}
+	seen := map[string]bool{}
+	names := map[string]bool{}
+	for _, container := range *containers {
+		if container.PortMappings == nil {
+			continue
+		}
+		for _, pm := range *container.PortMappings {
+			protocol := strings.ToUpper(pm.Protocol)
+			if len(protocol) == 0 {
+				protocol = "TCP"
+			}
+			key := protocol + "/" + strconv.Itoa(pm.ContainerPort)
+			if pm.ContainerPort == 0 || seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			name := servicePortName(pm)
+			port := ServicePort{
+				Name:       uniquePortName(name, names),
+				Protocol:   protocol,
+				Port:       pm.ContainerPort,
+				TargetPort: pm.ContainerPort,
+			}
+			if pm.HostPort > 0 {
+				s.Spec.Type = "NodePort"
+				// Kubernetes only accepts node ports in its default service
+				// node port range, otherwise one is assigned
+				if pm.HostPort >= 30000 && pm.HostPort <= 32767 {
+					port.NodePort = pm.HostPort
+				} else {
+					log.Printf("Host port %d for container %s is outside the NodePort range, one will be assigned", pm.HostPort, container.Name)
+				}
+			}
+			if port.Name != name {
+				log.Printf("Service port name %s is taken or too long, naming port %d of container %s %s", name, pm.ContainerPort, container.Name, port.Name)
+			}
+			if protocol == "TCP" && isHTTPPort(pm.Name) {
+				httpPorts = append(httpPorts, httpPort{Name: port.Name, Original: name})
+			}
+			s.Spec.Ports = append(s.Spec.Ports, port)
+		}
+	}
+	return httpPorts
+}
+
+// emitIngress creates an Ingress routing to the Service's HTTP ports. A single
+// port, or the port named "http", is served at the root, while "http-<suffix>"
+// ports are served under "/<suffix>"
+func emitIngress(name string, labels map[string]string, httpPorts []httpPort) *Ingress {
+	paths := []HTTPIngressPath{}
+	routed := map[string]string{}
+	for _, port := range httpPorts {
+		path := "/"
+		if len(httpPorts) > 1 {
+			path += strings.TrimPrefix(strings.TrimPrefix(port.Original, "http"), "-")
+		}
+		if existing, ok := routed[path]; ok {
+			log.Printf("Port %s already serves %s, dropping port %s from the Ingress", existing, path, port.Name)
+			continue
+		}
+		routed[path] = port.Name
+		paths = append(paths, HTTPIngressPath{
+			Path:     path,
+			PathType: "Prefix",
+			Backend: IngressBackend{
+				Service: IngressServiceBackend{
+					Name: name,
+					Port: ServiceBackendPort{Name: port.Name},
+				},
+			},
+		})
+	}
+	return &Ingress{
+		APIVersion: "networking.k8s.io/v1",
+		Kind:       "Ingress",
+		Metadata:   ObjectMeta{Name: name, Labels: labels},
+		Spec: IngressSpec{
+			Rules: []IngressRule{{HTTP: HTTPIngressRuleValue{Paths: paths}}},
+		},
+	}
+}
+
+// EmitContainers satisfies OutputFormat so Kubernetes Services can be emitted
+func (s Service) EmitContainers(input *transform.PodData) ([]byte, error) {
+	name := podName(input)
+	labels := podLabels(input)
+
+	output := &Service{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata:   ObjectMeta{Name: name, Labels: labels},
+		Spec: ServiceSpec{
+			Type:     "ClusterIP",
+			Selector: labels,
+		},
+	}
+	httpPorts := output.emitPorts(input.Containers)
+	if len(output.Spec.Ports) == 0 {
+		return nil, fmt.Errorf("no port mappings found for %s", name)
+	}
+
+	documents := []interface{}{output}
+	if len(httpPorts) > 0 {
+		documents = append(documents, emitIngress(name, labels, httpPorts))
+	}
+
+	var buffer bytes.Buffer
+	for i, document := range documents {
+		body, err := yaml.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buffer.WriteString("---\n")
+		}
+		buffer.Write(body)
+	}
+	return buffer.Bytes(), nil
+}
@@ -3,13 +3,28 @@ package main
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/micahhausler/container-tx/aci"
+	"github.com/micahhausler/container-tx/beanstalk"
+	"github.com/micahhausler/container-tx/chronos"
 	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/docker"
 	"github.com/micahhausler/container-tx/ecs"
+	"github.com/micahhausler/container-tx/kubernetes"
+	"github.com/micahhausler/container-tx/marathon"
+	"github.com/micahhausler/container-tx/nomad"
+	"github.com/micahhausler/container-tx/oci"
+	"github.com/micahhausler/container-tx/quadlet"
 	"github.com/micahhausler/container-tx/script"
+	"github.com/micahhausler/container-tx/systemd"
 	"github.com/micahhausler/container-tx/transform"
 	flag "github.com/ogier/pflag"
+	"gopkg.in/yaml.v2"
 )
 
 const versionNum = "0.0.1"
@@ -18,16 +33,90 @@ var version = flag.Bool("version", false, "print version and exit")
 
 var inputType = flag.StringP("input", "i", "compose", "The format of the input.")
 var outputType = flag.StringP("output", "o", "ecs", "The format of the output.")
+var outputDir = flag.StringP("output-dir", "d", "", "Write each file of a multi-file output format into this directory.")
+var secretARNs = flag.StringP("secret-arns", "s", "", "A YAML or JSON file mapping secret names to the SSM parameter or Secrets Manager ARNs ECS reads them from.")
 
 var inputMap = map[string]transform.InputFormat{
-	"compose": compose.DockerCompose{},
-	"ecs":     ecs.Task{},
+	"beanstalk":  beanstalk.Dockerrun{},
+	"chronos":    chronos.Job{},
+	"cli":        script.Script{},
+	"compose":    compose.DockerCompose{},
+	"ecs":        ecs.Task{},
+	"inspect":    docker.Inspect{},
+	"kubernetes": kubernetes.Deployment{},
+	"marathon":   marathon.App{},
+	"nomad":      nomad.Job{},
 }
 
 var outputMap = map[string]transform.OutputFormat{
-	"compose": compose.DockerCompose{},
-	"ecs":     ecs.Task{},
-	"cli":     script.Script{},
+	"compose":             compose.DockerCompose{},
+	"ecs":                 ecs.Task{},
+	"cli":                 script.Script{},
+	"cli-bash":            script.Script{Array: true},
+	"podman":              script.Script{Engine: "podman"},
+	"podman-pod":          script.Script{Engine: "podman", Pod: true},
+	"nerdctl":             script.Script{Engine: "nerdctl"},
+	"docker-create":       docker.Create{},
+	"kubernetes":          kubernetes.Deployment{},
+	"k8s-service":         kubernetes.Service{},
+	"knative":             kubernetes.KnativeService{},
+	"marathon":            marathon.App{},
+	"chronos":             chronos.Job{},
+	"systemd":             systemd.Unit{},
+	"quadlet":             quadlet.Pod{},
+	"nomad":               nomad.Job{},
+	"nomad-hcl":           nomad.HCL{},
+	"oci":                 oci.Bundle{},
+	"swarm":               compose.Stack{},
+	"beanstalk":           beanstalk.Dockerrun{},
+	"cloudformation":      ecs.CloudFormation{},
+	"cloudformation-json": ecs.CloudFormation{JSON: true},
+	"terraform":           ecs.Terraform{},
+	"aci":                 aci.ContainerGroup{},
+}
+
+// writeFiles writes each file of a FileOutputFormat into a directory. Names
+// come from container names, so any that would land outside the directory are
+// rejected before anything is written
+func writeFiles(dir string, files map[string][]byte) error {
+	for name := range files {
+		clean := filepath.Clean(name)
+		if filepath.IsAbs(name) || clean != name || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("refusing to write %q, which is not a relative path within %s", name, dir)
+		}
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, body, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapSecretARNs sets the ARN each of the pod's secrets is read from, from a
+// file mapping secret names to ARNs
+func mapSecretARNs(pod *transform.PodData, path string) error {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	arns := map[string]string{}
+	if err := yaml.Unmarshal(body, &arns); err != nil {
+		return err
+	}
+	for name, arn := range arns {
+		source, ok := pod.Secrets[name]
+		if !ok {
+			log.Printf("Skipping ARN for secret %s, which no container reads", name)
+			continue
+		}
+		source.ValueFrom = arn
+	}
+	return nil
 }
 
 func main() {
@@ -85,6 +174,32 @@ func main() {
 		fmt.Printf("Error ingesting file: %s \n", err)
 		os.Exit(1)
 	}
+
+	if len(*secretARNs) > 0 {
+		if err := mapSecretARNs(basePod, *secretARNs); err != nil {
+			fmt.Printf("Error reading secret ARNs: %s \n", err)
+			os.Exit(1)
+		}
+	}
+
+	if len(*outputDir) > 0 {
+		fileFormat, ok := outputFormat.(transform.FileOutputFormat)
+		if !ok {
+			fmt.Printf("Output type %s does not support writing to a directory\n", *outputType)
+			os.Exit(1)
+		}
+		files, err := fileFormat.EmitFiles(basePod)
+		if err != nil {
+			fmt.Printf("Error converting file: %s \n", err)
+			os.Exit(1)
+		}
+		if err := writeFiles(*outputDir, files); err != nil {
+			fmt.Printf("Error writing files: %s \n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	resp, err := outputFormat.EmitContainers(basePod)
 
 	if err != nil {
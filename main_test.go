@@ -0,0 +1,31 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestWriteFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "container-tx")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = writeFiles(dir, map[string][]byte{"web.service": []byte("web"), "web/config.json": []byte("{}")})
+	assert.Nil(t, err)
+	body, err := ioutil.ReadFile(filepath.Join(dir, "web", "config.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, "{}", string(body))
+
+	for _, name := range []string{"/web.service", "../x.container", "web/../../x.json", "..", "./web.service"} {
+		err = writeFiles(dir, map[string][]byte{"db.service": []byte("db"), name: []byte("x")})
+		assert.NotNil(t, err, name)
+	}
+	_, err = os.Stat(filepath.Join(dir, "db.service"))
+	assert.True(t, os.IsNotExist(err))
+}
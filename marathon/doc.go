@@ -0,0 +1,2 @@
+// Package marathon is for ingesting and emitting Marathon application and group definitions
+package marathon
@@ -0,0 +1,633 @@
+package marathon
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+var invalidIDChars = regexp.MustCompile("[^a-z0-9.-]+")
+
+// sanitizeID converts a string into a valid Marathon path segment
+func sanitizeID(id string) string {
+	return strings.Trim(invalidIDChars.ReplaceAllString(strings.ToLower(id), "-"), "-.")
+}
+
+// Parameter is a type for storing arbitrary docker run parameters
+type Parameter struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Parameters is a composite type for slices of Parameter
+type Parameters []Parameter
+
+func (p *Parameters) add(key, value string) {
+	*p = append(*p, Parameter{Key: key, Value: value})
+}
+
+func (a *App) addParameter(key, value string) {
+	if a.Container.Docker.Parameters == nil {
+		a.Container.Docker.Parameters = &Parameters{}
+	}
+	a.Container.Docker.Parameters.add(key, value)
+}
+
+// sortedKeys returns a map's keys in order, so parameters are emitted consistently
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Ingest applies docker run parameters to an intermediate container
+func (p Parameters) Ingest(ir *transform.Container) {
+	keyValue := func(value string) (string, string) {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) > 1 {
+			return parts[0], parts[1]
+		}
+		return parts[0], ""
+	}
+	for _, param := range p {
+		switch param.Key {
+		case "dns":
+			ir.DNS = append(ir.DNS, param.Value)
+		case "dns-search":
+			ir.Domain = append(ir.Domain, param.Value)
+		case "entrypoint":
+			ir.Entrypoint = []string{param.Value}
+		case "env-file":
+			ir.EnvFile = append(ir.EnvFile, param.Value)
+		case "hostname":
+			ir.Hostname = param.Value
+		case "label":
+			if ir.Labels == nil {
+				ir.Labels = map[string]string{}
+			}
+			k, v := keyValue(param.Value)
+			ir.Labels[k] = v
+		case "log-driver":
+			if ir.Logging == nil {
+				ir.Logging = &transform.Logging{}
+			}
+			ir.Logging.Driver = param.Value
+		case "log-opt":
+			if ir.Logging == nil {
+				ir.Logging = &transform.Logging{}
+			}
+			if ir.Logging.Options == nil {
+				ir.Logging.Options = map[string]string{}
+			}
+			k, v := keyValue(param.Value)
+			ir.Logging.Options[k] = v
+		case "pid":
+			ir.Pid = param.Value
+		case "stop-signal":
+			ir.StopSignal = param.Value
+		case "user":
+			ir.User = param.Value
+		case "volume":
+			if ir.Volumes == nil {
+				ir.Volumes = &transform.IntermediateVolumes{}
+			}
+			*ir.Volumes = append(*ir.Volumes, transform.IntermediateVolume{Container: param.Value})
+		case "volumes-from":
+			ir.VolumesFrom = append(ir.VolumesFrom, param.Value)
+		case "workdir":
+			ir.WorkDir = param.Value
+		default:
+			log.Printf("Ignoring unsupported docker parameter %s for container %s", param.Key, ir.Name)
+		}
+	}
+}
+
+// EmitParameters converts an intermediate container's docker run options into parameters
+func EmitParameters(container transform.Container) Parameters {
+	p := Parameters{}
+	for _, dns := range container.DNS {
+		p.add("dns", dns)
+	}
+	for _, domain := range container.Domain {
+		p.add("dns-search", domain)
+	}
+	if len(container.Entrypoint) > 0 {
+		p.add("entrypoint", container.Entrypoint[0])
+	}
+	if len(container.Hostname) > 0 {
+		p.add("hostname", container.Hostname)
+	}
+	for _, k := range sortedKeys(container.Labels) {
+		p.add("label", k+"="+container.Labels[k])
+	}
+	if container.Logging != nil {
+		p.add("log-driver", container.Logging.Driver)
+		for _, k := range sortedKeys(container.Logging.Options) {
+			p.add("log-opt", k+"="+container.Logging.Options[k])
+		}
+	}
+	if len(container.Pid) > 0 {
+		p.add("pid", container.Pid)
+	}
+	if len(container.User) > 0 {
+		p.add("user", container.User)
+	}
+	for _, vf := range container.VolumesFrom {
+		p.add("volumes-from", vf)
+	}
+	if len(container.WorkDir) > 0 {
+		p.add("workdir", container.WorkDir)
+	}
+	return p
+}
+
+// PortMapping is a type for storing Marathon docker port information
+type PortMapping struct {
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort"`
+	ServicePort   int    `json:"servicePort,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+	Name          string `json:"name,omitempty"`
+}
+
+// PortMappings is a composite type for slices of PortMapping
+type PortMappings []PortMapping
+
+func (a App) ingestPortMappings() *transform.PortMappings {
+	mappings := a.Container.PortMappings
+	if a.Container.Docker.PortMappings != nil {
+		mappings = a.Container.Docker.PortMappings
+	}
+	if mappings != nil && len(*mappings) > 0 {
+		response := transform.PortMappings{}
+		for _, pm := range *mappings {
+			protocol := strings.ToLower(pm.Protocol)
+			if len(protocol) == 0 {
+				protocol = "tcp"
+			}
+			response = append(response, transform.PortMapping{
+				ContainerPort: pm.ContainerPort,
+				HostPort:      pm.HostPort,
+				Protocol:      protocol,
+				Name:          pm.Name,
+			})
+		}
+		return &response
+	}
+	return nil
+}
+
+func (a *App) emitPortMappings(in *transform.PortMappings) {
+	if in != nil && len(*in) > 0 {
+		output := PortMappings{}
+		for _, pm := range *in {
+			if pm.ContainerPort == 0 {
+				continue
+			}
+			output = append(output, PortMapping{
+				ContainerPort: pm.ContainerPort,
+				HostPort:      pm.HostPort,
+				Protocol:      strings.ToLower(pm.Protocol),
+				Name:          pm.Name,
+			})
+		}
+		a.Container.Docker.PortMappings = &output
+	}
+}
+
+// Volume is a type for storing Marathon container volume information
+type Volume struct {
+	ContainerPath string `json:"containerPath"`
+	HostPath      string `json:"hostPath"`
+	Mode          string `json:"mode"`
+}
+
+// Volumes is a composite type for slices of Volume
+type Volumes []Volume
+
+func (a App) ingestVolumes(ir *transform.Container) {
+	if a.Container.Volumes == nil {
+		return
+	}
+	for _, vol := range *a.Container.Volumes {
+		if ir.Volumes == nil {
+			ir.Volumes = &transform.IntermediateVolumes{}
+		}
+		*ir.Volumes = append(*ir.Volumes, transform.IntermediateVolume{
+			Host:      vol.HostPath,
+			Container: vol.ContainerPath,
+			ReadOnly:  strings.ToUpper(vol.Mode) == "RO",
+		})
+	}
+}
+
+func (a *App) emitVolumes(vols *transform.IntermediateVolumes) {
+	if vols == nil {
+		return
+	}
+	output := Volumes{}
+	for _, volume := range *vols {
+		if len(volume.Host) == 0 {
+			// Marathon only declares host volumes, so anonymous volumes are
+			// passed through to docker instead
+			a.addParameter("volume", volume.Container)
+			continue
+		}
+		mode := "RW"
+		if volume.ReadOnly {
+			mode = "RO"
+		}
+		output = append(output, Volume{
+			ContainerPath: volume.Container,
+			HostPath:      volume.Host,
+			Mode:          mode,
+		})
+	}
+	if len(output) > 0 {
+		a.Container.Volumes = &output
+	}
+}
+
+// Docker is a type for storing Marathon docker container information
+type Docker struct {
+	Image          string        `json:"image"`
+	Network        string        `json:"network,omitempty"`
+	PortMappings   *PortMappings `json:"portMappings,omitempty"`
+	Privileged     bool          `json:"privileged,omitempty"`
+	Parameters     *Parameters   `json:"parameters,omitempty"`
+	ForcePullImage bool          `json:"forcePullImage,omitempty"`
+}
+
+// Container is a type for storing Marathon container information. Newer
+// Marathon versions declare port mappings on the container instead of docker
+type Container struct {
+	Type         string        `json:"type"`
+	Docker       Docker        `json:"docker"`
+	PortMappings *PortMappings `json:"portMappings,omitempty"`
+	Volumes      *Volumes      `json:"volumes,omitempty"`
+}
+
+// Fetch is a type for storing a URI to fetch into the sandbox
+type Fetch struct {
+	URI        string `json:"uri"`
+	Executable bool   `json:"executable,omitempty"`
+	Extract    bool   `json:"extract,omitempty"`
+	Cache      bool   `json:"cache,omitempty"`
+}
+
+func (a App) ingestFetch() []*transform.Fetch {
+	response := []*transform.Fetch{}
+	for _, uri := range a.URIs {
+		response = append(response, &transform.Fetch{URI: uri})
+	}
+	for _, fetch := range a.Fetch {
+		response = append(response, &transform.Fetch{URI: fetch.URI})
+	}
+	if len(response) > 0 {
+		return response
+	}
+	return nil
+}
+
+func (a *App) emitFetch(in []*transform.Fetch) {
+	for _, fetch := range in {
+		if fetch != nil {
+			a.Fetch = append(a.Fetch, Fetch{URI: fetch.URI})
+		}
+	}
+}
+
+// Command is a type for storing a Marathon command health check's command
+type Command struct {
+	Value string `json:"value"`
+}
+
+// HealthCheck is a type for storing Marathon health check information
+type HealthCheck struct {
+	Protocol               string   `json:"protocol"`
+	Path                   string   `json:"path,omitempty"`
+	PortIndex              *int     `json:"portIndex,omitempty"`
+	Port                   int      `json:"port,omitempty"`
+	Command                *Command `json:"command,omitempty"`
+	IntervalSeconds        int      `json:"intervalSeconds,omitempty"`
+	TimeoutSeconds         int      `json:"timeoutSeconds,omitempty"`
+	MaxConsecutiveFailures int      `json:"maxConsecutiveFailures,omitempty"`
+}
+
+// portIndex returns the index of the emitted port mapping for a container port, or -1
+func portIndex(mappings *transform.PortMappings, port int) int {
+	if mappings == nil || port == 0 {
+		return -1
+	}
+	index := 0
+	for _, pm := range *mappings {
+		if pm.ContainerPort == 0 {
+			continue
+		}
+		if pm.ContainerPort == port {
+			return index
+		}
+		index++
+	}
+	return -1
+}
+
+func (a App) ingestHealthChecks(mappings *transform.PortMappings) []*transform.HealthCheck {
+	response := []*transform.HealthCheck{}
+	for _, hc := range a.HealthChecks {
+		check := &transform.HealthCheck{
+			Interval:         hc.IntervalSeconds,
+			Timeout:          hc.TimeoutSeconds,
+			FailureThreshold: hc.MaxConsecutiveFailures,
+		}
+		switch strings.TrimPrefix(hc.Protocol, "MESOS_") {
+		case "COMMAND":
+			if hc.Command != nil {
+				check.Exec = hc.Command.Value
+			}
+		case "HTTP", "HTTPS":
+			check.HTTPPath = hc.Path
+			if len(check.HTTPPath) == 0 {
+				check.HTTPPath = "/"
+			}
+			check.Scheme = strings.ToLower(strings.TrimPrefix(hc.Protocol, "MESOS_"))
+		}
+		check.Port = hc.Port
+		if hc.PortIndex != nil {
+			if mappings != nil && *hc.PortIndex >= 0 && *hc.PortIndex < len(*mappings) {
+				check.Port = (*mappings)[*hc.PortIndex].ContainerPort
+			} else {
+				log.Printf("Ignoring health check port index %d of app %s, which has no such port", *hc.PortIndex, a.ID)
+			}
+		}
+		response = append(response, check)
+	}
+	if len(response) > 0 {
+		return response
+	}
+	return nil
+}
+
+func (a *App) emitHealthChecks(in []*transform.HealthCheck, mappings *transform.PortMappings) {
+	for _, check := range in {
+		if check == nil {
+			continue
+		}
+		hc := HealthCheck{
+			IntervalSeconds:        check.Interval,
+			TimeoutSeconds:         check.Timeout,
+			MaxConsecutiveFailures: check.FailureThreshold,
+		}
+		switch {
+		case len(check.Exec) > 0:
+			hc.Protocol = "COMMAND"
+			hc.Command = &Command{Value: check.Exec}
+		case len(check.HTTPPath) > 0:
+			hc.Protocol = "HTTP"
+			if strings.ToLower(check.Scheme) == "https" {
+				hc.Protocol = "HTTPS"
+			}
+			hc.Path = check.HTTPPath
+		default:
+			hc.Protocol = "TCP"
+		}
+		if hc.Protocol != "COMMAND" {
+			// Marathon checks ports by their index in the port mappings,
+			// unknown ports are checked directly
+			if index := portIndex(mappings, check.Port); index >= 0 {
+				hc.PortIndex = &index
+			} else if check.Port > 0 {
+				hc.Port = check.Port
+			} else {
+				index = 0
+				hc.PortIndex = &index
+			}
+		}
+		a.HealthChecks = append(a.HealthChecks, hc)
+	}
+}
+
+// App represents a Marathon application. It implements InputFormat and
+// OutputFormat, and pods with more than one container are emitted as a Group
+// of applications
+type App struct {
+	ID           string            `json:"id"`
+	Cmd          string            `json:"cmd,omitempty"`
+	Args         []string          `json:"args,omitempty"`
+	CPUs         float64           `json:"cpus,omitempty"`
+	Mem          float64           `json:"mem,omitempty"`
+	Instances    int               `json:"instances"`
+	Container    *Container        `json:"container,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	URIs         []string          `json:"uris,omitempty"`
+	Fetch        []Fetch           `json:"fetch,omitempty"`
+	HealthChecks []HealthCheck     `json:"healthChecks,omitempty"`
+	Dependencies []string          `json:"dependencies,omitempty"`
+}
+
+// Group represents a Marathon group of applications and nested groups
+type Group struct {
+	ID     string  `json:"id"`
+	Apps   []App   `json:"apps"`
+	Groups []Group `json:"groups,omitempty"`
+}
+
+// resolveID converts a possibly relative id into an absolute one
+func resolveID(parent, id string) string {
+	if strings.HasPrefix(id, "/") {
+		return id
+	}
+	return strings.TrimSuffix(parent, "/") + "/" + id
+}
+
+// relativeID converts an absolute id into a container name relative to a group
+func relativeID(prefix, id string) string {
+	id = strings.TrimPrefix(strings.TrimPrefix(id, strings.TrimSuffix(prefix, "/")+"/"), "/")
+	return strings.Replace(id, "/", "-", -1)
+}
+
+// flatten returns every application in the group and its nested groups, with
+// their ids and dependencies made absolute
+func (g Group) flatten(parent string) []App {
+	groupID := resolveID(parent, g.ID)
+	apps := []App{}
+	for _, app := range g.Apps {
+		app.ID = resolveID(groupID, app.ID)
+		dependencies := []string{}
+		for _, dependency := range app.Dependencies {
+			dependencies = append(dependencies, resolveID(groupID, dependency))
+		}
+		app.Dependencies = dependencies
+		apps = append(apps, app)
+	}
+	for _, group := range g.Groups {
+		apps = append(apps, group.flatten(groupID)...)
+	}
+	return apps
+}
+
+// ingestApp converts a Marathon application into an intermediate container
+func ingestApp(app App, prefix string) transform.Container {
+	ir := transform.Container{}
+	if len(app.Args) > 0 {
+		ir.Command = app.Args
+	} else if len(app.Cmd) > 0 {
+		ir.Command = []string{app.Cmd}
+		ir.ShellForm = true
+	}
+	ir.CPU = int(app.CPUs * 1024)
+	ir.Environment = app.Env
+	ir.Fetch = app.ingestFetch()
+	ir.Memory = int(app.Mem * (1 << 20))
+	ir.Name = relativeID(prefix, app.ID)
+	ir.Replicas = app.Instances
+	for _, dependency := range app.Dependencies {
+		ir.Links = append(ir.Links, relativeID(prefix, dependency))
+	}
+	if app.Container != nil {
+		ir.Image = app.Container.Docker.Image
+		if strings.ToUpper(app.Container.Docker.Network) == "HOST" {
+			ir.NetworkMode = "host"
+		}
+		ir.Privileged = app.Container.Docker.Privileged
+		if app.Container.Docker.ForcePullImage {
+			ir.PullImagePolicy = "always"
+		}
+		ir.PortMappings = app.ingestPortMappings()
+		if app.Container.Docker.Parameters != nil {
+			app.Container.Docker.Parameters.Ingest(&ir)
+		}
+		app.ingestVolumes(&ir)
+	}
+	ir.HealthChecks = app.ingestHealthChecks(ir.PortMappings)
+	return ir
+}
+
+// emitApp converts an intermediate container into a Marathon application
+func emitApp(input *transform.PodData, container transform.Container, groupID string) App {
+	app := App{
+		ID:        sanitizeID(container.Name),
+		Instances: container.Replicas,
+		Container: &Container{
+			Type:   "DOCKER",
+			Docker: Docker{Image: container.Image, Network: "BRIDGE"},
+		},
+	}
+	if len(groupID) == 0 {
+		app.ID = "/" + app.ID
+	}
+	if app.Instances == 0 {
+		app.Instances = input.Replicas
+	}
+	if app.Instances == 0 {
+		app.Instances = 1
+	}
+	if container.ShellForm && len(container.Entrypoint) < 2 {
+		app.Cmd = container.CommandLine()
+	} else if args := container.RunArgs(); len(args) > 0 {
+		app.Args = args
+	}
+	// Marathon measures CPUs in cores and memory in MB
+	app.CPUs = float64(container.CPU) / 1024
+	app.Mem = float64(container.Memory) / (1 << 20)
+	if container.NetworkMode == "host" || input.HostNetwork {
+		app.Container.Docker.Network = "HOST"
+	}
+	app.Container.Docker.Privileged = container.Privileged
+	app.Container.Docker.ForcePullImage = container.PullImagePolicy == "always"
+	app.emitPortMappings(container.PortMappings)
+	if params := EmitParameters(container); len(params) > 0 {
+		app.Container.Docker.Parameters = &params
+	}
+	app.emitVolumes(container.Volumes)
+	if len(container.Environment) > 0 {
+		app.Env = container.Environment
+	}
+	if len(input.GlobalLabels) > 0 {
+		app.Labels = input.GlobalLabels
+	}
+	app.emitFetch(container.Fetch)
+	app.emitHealthChecks(container.HealthChecks, container.PortMappings)
+	if len(groupID) > 0 {
+		for _, link := range container.Links {
+			app.Dependencies = append(app.Dependencies, groupID+"/"+sanitizeID(strings.SplitN(link, ":", 2)[0]))
+		}
+	}
+	return app
+}
+
+// IngestContainers satisfies InputFormat so Marathon applications and groups can be ingested
+func (a App) IngestContainers(input io.ReadCloser) (*transform.PodData, error) {
+
+	body, err := ioutil.ReadAll(input)
+	defer input.Close()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	group := Group{}
+	err = json.Unmarshal(body, &group)
+	if err != nil {
+		return nil, err
+	}
+
+	outputPod := transform.PodData{}
+	var apps []App
+	var prefix string
+	if len(group.Apps) > 0 || len(group.Groups) > 0 {
+		prefix = resolveID("", group.ID)
+		apps = group.flatten("")
+	} else {
+		err = json.Unmarshal(body, &a)
+		if err != nil {
+			return nil, err
+		}
+		a.ID = resolveID("", a.ID)
+		prefix = a.ID[:strings.LastIndex(a.ID, "/")]
+		apps = Group{ID: prefix, Apps: []App{a}}.flatten("")
+		outputPod.Replicas = a.Instances
+	}
+	outputPod.Name = strings.Replace(strings.Trim(group.ID, "/"), "/", "-", -1)
+
+	containers := transform.Containers{}
+	for _, app := range apps {
+		for k, v := range app.Labels {
+			if outputPod.GlobalLabels == nil {
+				outputPod.GlobalLabels = map[string]string{}
+			}
+			outputPod.GlobalLabels[k] = v
+		}
+		containers = append(containers, ingestApp(app, prefix))
+	}
+	sort.Sort(containers)
+	outputPod.Containers = &containers
+
+	return &outputPod, nil
+}
+
+// EmitContainers satisfies OutputFormat so Marathon applications can be emitted
+func (a App) EmitContainers(input *transform.PodData) ([]byte, error) {
+	if len(*input.Containers) == 1 {
+		output := emitApp(input, (*input.Containers)[0], "")
+		return json.MarshalIndent(output, "", "    ")
+	}
+
+	output := &Group{ID: "/" + sanitizeID(input.Name)}
+	if output.ID == "/" {
+		output.ID = "/group"
+	}
+	for _, container := range *input.Containers {
+		output.Apps = append(output.Apps, emitApp(input, container, output.ID))
+	}
+	return json.MarshalIndent(output, "", "    ")
+}
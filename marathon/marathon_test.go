@@ -0,0 +1,172 @@
+package marathon
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func compareFixture(t *testing.T, got []byte, fixture string) {
+	expected, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestIngestContainers(t *testing.T) {
+	for _, fixture := range []string{"./test_fixtures/app.json", "./test_fixtures/group.json"} {
+		f, err := os.Open(fixture)
+		if err != nil {
+			t.Errorf("Failed to open fixture: %s", err)
+		}
+
+		_, err = App{}.IngestContainers(f)
+		if err != nil {
+			t.Errorf("Failed to ingest containers: %s", err)
+		}
+	}
+}
+
+func TestIngestApp(t *testing.T) {
+	f, err := os.Open("./test_fixtures/app.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := App{}.IngestContainers(f)
+	if err != nil {
+		t.Fatalf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, 3, bp.Replicas)
+	container := (*bp.Containers)[0]
+	assert.Equal(t, "web", container.Name)
+	assert.Equal(t, 512, container.CPU)
+	assert.Equal(t, 128<<20, container.Memory)
+	assert.Equal(t, []*transform.HealthCheck{
+		{HTTPPath: "/health", Port: 443, Scheme: "https", Interval: 10, Timeout: 5, FailureThreshold: 3},
+		{Port: 80},
+		{Exec: "curl -f http://localhost/"},
+	}, container.HealthChecks)
+}
+
+func TestIngestPortIndexOutOfRange(t *testing.T) {
+	body := `{
+  "id": "web",
+  "container": {"docker": {"image": "nginx", "portMappings": [{"containerPort": 80}]}},
+  "healthChecks": [
+    {"protocol": "TCP", "portIndex": -1},
+    {"protocol": "TCP", "portIndex": 1},
+    {"protocol": "TCP", "portIndex": 0}
+  ]
+}`
+	bp, err := App{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Fatalf("Failed to ingest containers: %s", err)
+	}
+	assert.Equal(t, []*transform.HealthCheck{{}, {}, {Port: 80}}, (*bp.Containers)[0].HealthChecks)
+}
+
+func TestIngestNestedGroup(t *testing.T) {
+	f, err := os.Open("./test_fixtures/nested-group.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := App{}.IngestContainers(f)
+	if err != nil {
+		t.Fatalf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, "product", bp.Name)
+	assert.Equal(t, map[string]string{"HAPROXY_GROUP": "external"}, bp.GlobalLabels)
+	assert.Len(t, *bp.Containers, 2)
+
+	mongo := (*bp.Containers)[0]
+	assert.Equal(t, "database-mongo", mongo.Name)
+	assert.Equal(t, "host", mongo.NetworkMode)
+	assert.Equal(t, map[string]string{"tier": "db"}, mongo.Labels)
+	assert.Equal(t, "syslog", mongo.Logging.Driver)
+	assert.Equal(t, transform.IntermediateVolumes{
+		{Host: "/var/lib/mongo", Container: "/data/db"},
+	}, *mongo.Volumes)
+	assert.Equal(t, []*transform.HealthCheck{{Port: 27017}}, mongo.HealthChecks)
+
+	web := (*bp.Containers)[1]
+	assert.Equal(t, "service-web", web.Name)
+	assert.Equal(t, []string{"python3 -m http.server 8080"}, web.Command)
+	assert.True(t, web.ShellForm)
+	assert.Equal(t, 2, web.Replicas)
+	assert.Equal(t, []string{"database-mongo"}, web.Links)
+	assert.Equal(t, []*transform.Fetch{
+		{URI: "https://example.com/config.tar.gz"},
+		{URI: "https://example.com/app.tar.gz"},
+	}, web.Fetch)
+	assert.Equal(t, []*transform.HealthCheck{
+		{HTTPPath: "/health", Port: 8443, Scheme: "https", Interval: 60, Timeout: 20, FailureThreshold: 3},
+		{Exec: "curl -f http://localhost:8080/"},
+	}, web.HealthChecks)
+}
+
+func TestEmitContainers(t *testing.T) {
+	cf := compose.DockerCompose{}
+
+	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := cf.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := App{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	compareFixture(t, got, "./test_fixtures/group.json")
+}
+
+func TestEmitApp(t *testing.T) {
+	bp := &transform.PodData{
+		Name:         "web",
+		GlobalLabels: map[string]string{"HAPROXY_GROUP": "external"},
+		Replicas:     3,
+		Containers: &transform.Containers{{
+			Name:        "web",
+			Image:       "nginx",
+			CPU:         512,
+			Memory:      128 << 20,
+			Environment: map[string]string{"NGINX_PORT": "80"},
+			PortMappings: &transform.PortMappings{
+				{ContainerPort: 80, Protocol: "tcp", Name: "http"},
+				{ContainerPort: 443, Protocol: "tcp", Name: "https"},
+			},
+			Fetch: []*transform.Fetch{{URI: "https://example.com/nginx.conf"}},
+			HealthChecks: []*transform.HealthCheck{
+				{HTTPPath: "/health", Port: 443, Scheme: "https", Interval: 10, Timeout: 5, FailureThreshold: 3},
+				{Port: 80},
+				{Exec: "curl -f http://localhost/"},
+			},
+		}},
+	}
+
+	got, err := App{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	compareFixture(t, got, "./test_fixtures/app.json")
+}
@@ -0,0 +1,2 @@
+// Package nomad is for ingesting and emitting HashiCorp Nomad jobs
+package nomad
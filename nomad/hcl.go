@@ -0,0 +1,78 @@
+package nomad
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// hclEscaper escapes HCL string escapes and template sequences
+var hclEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+
+// quote returns an HCL string literal
+func quote(s string) string {
+	return `"` + hclEscaper.Replace(s) + `"`
+}
+
+// list returns an HCL list of string literals
+func list(items []string) string {
+	quoted := []string{}
+	for _, item := range items {
+		quoted = append(quoted, quote(item))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// object returns an HCL object of string literals, with its closing brace
+// at the given indent
+func object(m map[string]string, indent int) string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	padding := strings.Repeat(" ", indent)
+	lines := []string{"{"}
+	for _, k := range keys {
+		lines = append(lines, padding+"  "+quote(k)+" = "+quote(m[k]))
+	}
+	lines = append(lines, padding+"}")
+	return strings.Join(lines, "\n")
+}
+
+// objects returns an HCL list of objects, as used by docker driver blocks
+// that accept a list of maps
+func objects(ms []map[string]string, indent int) string {
+	items := []string{}
+	for _, m := range ms {
+		items = append(items, object(m, indent))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// HCL represents a Nomad job written in HCL. It implements OutputFormat
+type HCL struct{}
+
+// EmitContainers satisfies OutputFormat so Nomad HCL jobs can be emitted
+func (h HCL) EmitContainers(input *transform.PodData) ([]byte, error) {
+
+	funcMap := template.FuncMap{
+		"list":    list,
+		"object":  object,
+		"objects": objects,
+		"quote":   quote,
+	}
+
+	t := template.Must(template.New("job").Funcs(funcMap).Parse(jobTemplate))
+
+	var buffer bytes.Buffer
+	err := t.Execute(&buffer, emitJob(input))
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
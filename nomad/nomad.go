@@ -0,0 +1,513 @@
+package nomad
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+var invalidLabelChars = regexp.MustCompile("[^a-zA-Z0-9_]+")
+
+// portLabel returns a Nomad port label for a port mapping, generating one from
+// the port when the mapping has no name
+func portLabel(pm transform.PortMapping) string {
+	if label := strings.Trim(invalidLabelChars.ReplaceAllString(pm.Name, "_"), "_"); len(label) > 0 {
+		return label
+	}
+	port := pm.ContainerPort
+	if port == 0 {
+		port = pm.HostPort
+	}
+	return "port_" + strconv.Itoa(port)
+}
+
+// Port is a type for storing a Nomad network port
+type Port struct {
+	Label string `json:"Label"`
+	Value int    `json:"Value,omitempty"`
+	To    int    `json:"To,omitempty"`
+}
+
+// Network is a type for storing a Nomad task group network
+type Network struct {
+	Mode          string `json:"Mode,omitempty"`
+	ReservedPorts []Port `json:"ReservedPorts,omitempty"`
+	DynamicPorts  []Port `json:"DynamicPorts,omitempty"`
+}
+
+// VolumeRequest is a type for storing a Nomad task group volume
+type VolumeRequest struct {
+	Name     string `json:"Name"`
+	Type     string `json:"Type"`
+	Source   string `json:"Source"`
+	ReadOnly bool   `json:"ReadOnly,omitempty"`
+}
+
+// VolumeMount is a type for storing a task's mount of a task group volume
+type VolumeMount struct {
+	Volume      string `json:"Volume"`
+	Destination string `json:"Destination"`
+	ReadOnly    bool   `json:"ReadOnly,omitempty"`
+}
+
+// Resources is a type for storing a Nomad task's resources
+type Resources struct {
+	CPU      int `json:"CPU,omitempty"`
+	MemoryMB int `json:"MemoryMB,omitempty"`
+}
+
+// Artifact is a type for storing a file Nomad downloads into the task
+type Artifact struct {
+	GetterSource string `json:"GetterSource"`
+}
+
+// Mount is a type for storing a docker driver mount
+type Mount struct {
+	Type     string `json:"type"`
+	Target   string `json:"target"`
+	Source   string `json:"source,omitempty"`
+	ReadOnly bool   `json:"readonly,omitempty"`
+}
+
+// Logging is a type for storing docker driver logging configuration
+type Logging struct {
+	Type   string              `json:"type"`
+	Config []map[string]string `json:"config,omitempty"`
+}
+
+// DockerConfig is a type for storing a docker driver task's configuration
+type DockerConfig struct {
+	Image            string              `json:"image"`
+	Command          string              `json:"command,omitempty"`
+	Args             []string            `json:"args,omitempty"`
+	Entrypoint       []string            `json:"entrypoint,omitempty"`
+	DNSServers       []string            `json:"dns_servers,omitempty"`
+	DNSSearchDomains []string            `json:"dns_search_domains,omitempty"`
+	ForcePull        bool                `json:"force_pull,omitempty"`
+	Hostname         string              `json:"hostname,omitempty"`
+	Labels           []map[string]string `json:"labels,omitempty"`
+	Logging          *Logging            `json:"logging,omitempty"`
+	Mounts           []Mount             `json:"mount,omitempty"`
+	NetworkMode      string              `json:"network_mode,omitempty"`
+	PidMode          string              `json:"pid_mode,omitempty"`
+	Ports            []string            `json:"ports,omitempty"`
+	Privileged       bool                `json:"privileged,omitempty"`
+	Volumes          []string            `json:"volumes,omitempty"`
+	WorkDir          string              `json:"work_dir,omitempty"`
+}
+
+// Task is a type for storing a Nomad task
+type Task struct {
+	Name         string            `json:"Name"`
+	Driver       string            `json:"Driver"`
+	User         string            `json:"User,omitempty"`
+	Config       DockerConfig      `json:"Config"`
+	Env          map[string]string `json:"Env,omitempty"`
+	Resources    *Resources        `json:"Resources,omitempty"`
+	VolumeMounts []VolumeMount     `json:"VolumeMounts,omitempty"`
+	Artifacts    []Artifact        `json:"Artifacts,omitempty"`
+	KillSignal   string            `json:"KillSignal,omitempty"`
+}
+
+// TaskGroup is a type for storing a Nomad task group
+type TaskGroup struct {
+	Name     string                   `json:"Name"`
+	Count    int                      `json:"Count"`
+	Networks []Network                `json:"Networks,omitempty"`
+	Volumes  map[string]VolumeRequest `json:"Volumes,omitempty"`
+	Tasks    []Task                   `json:"Tasks"`
+}
+
+// Job represents a Nomad job, with one task group for the pod and one docker
+// task per container. It implements InputFormat and OutputFormat
+type Job struct {
+	ID          string            `json:"ID"`
+	Name        string            `json:"Name"`
+	Type        string            `json:"Type"`
+	Datacenters []string          `json:"Datacenters"`
+	Meta        map[string]string `json:"Meta,omitempty"`
+	TaskGroups  []TaskGroup       `json:"TaskGroups"`
+}
+
+// jobSpec is the envelope Nomad's API and `nomad job run -output` use for a job
+type jobSpec struct {
+	Job *Job `json:"Job"`
+}
+
+// ingestPortMappings converts the group ports a task uses into port mappings
+func (g TaskGroup) ingestPortMappings(t Task) *transform.PortMappings {
+	ports := map[string]transform.PortMapping{}
+	for _, network := range g.Networks {
+		for _, port := range network.ReservedPorts {
+			to := port.To
+			if to == 0 {
+				to = port.Value
+			}
+			ports[port.Label] = transform.PortMapping{Name: port.Label, HostPort: port.Value, ContainerPort: to}
+		}
+		for _, port := range network.DynamicPorts {
+			ports[port.Label] = transform.PortMapping{Name: port.Label, ContainerPort: port.To}
+		}
+	}
+
+	response := transform.PortMappings{}
+	for _, label := range t.Config.Ports {
+		pm, ok := ports[label]
+		if !ok || pm.ContainerPort == 0 {
+			log.Printf("Ignoring port %s for task %s: no container port is mapped", label, t.Name)
+			continue
+		}
+		response = append(response, pm)
+	}
+	if len(response) > 0 {
+		return &response
+	}
+	return nil
+}
+
+// emitPortMappings adds a container's port mappings to the group network and
+// returns the port labels for the task
+func (g *TaskGroup) emitPortMappings(container transform.Container) []string {
+	if container.PortMappings == nil {
+		return nil
+	}
+	if len(g.Networks) == 0 {
+		g.Networks = []Network{{}}
+	}
+	network := &g.Networks[0]
+
+	ports := map[string]Port{}
+	for _, port := range append(network.ReservedPorts, network.DynamicPorts...) {
+		ports[port.Label] = port
+	}
+
+	labels := []string{}
+	for _, pm := range *container.PortMappings {
+		label := portLabel(pm)
+		if len(pm.HostIP) > 0 {
+			log.Printf("Ignoring host IP %s for port %s of container %s", pm.HostIP, label, container.Name)
+		}
+		// Containers share a label for the same port, and number it otherwise
+		port := Port{Label: label, Value: pm.HostPort, To: pm.ContainerPort}
+		for i := 2; ; i++ {
+			if existing, ok := ports[port.Label]; !ok || existing == port {
+				break
+			}
+			port.Label = label + "_" + strconv.Itoa(i)
+		}
+		if port.Label != label {
+			log.Printf("Port label %s is used by another port, labeling port %d of container %s %s", label, pm.ContainerPort, container.Name, port.Label)
+		}
+		labels = append(labels, port.Label)
+		if _, ok := ports[port.Label]; ok {
+			continue
+		}
+		ports[port.Label] = port
+		if pm.HostPort > 0 {
+			network.ReservedPorts = append(network.ReservedPorts, port)
+		} else {
+			network.DynamicPorts = append(network.DynamicPorts, port)
+		}
+	}
+	return labels
+}
+
+// parseVolume parses a docker driver volume of the form host:container[:ro]
+func parseVolume(volume string) transform.IntermediateVolume {
+	iv := transform.IntermediateVolume{}
+	parts := strings.Split(volume, ":")
+	if len(parts) == 1 {
+		iv.Container = parts[0]
+		return iv
+	}
+	iv.Host = parts[0]
+	iv.Container = parts[1]
+	iv.ReadOnly = len(parts) > 2 && parts[2] == "ro"
+	return iv
+}
+
+// ingestVolumes converts a task's mounts and volume mounts into volumes
+func (g TaskGroup) ingestVolumes(t Task) *transform.IntermediateVolumes {
+	response := transform.IntermediateVolumes{}
+	for _, mount := range t.Config.Mounts {
+		iv := transform.IntermediateVolume{Container: mount.Target, ReadOnly: mount.ReadOnly}
+		switch mount.Type {
+		case "bind":
+			iv.Host = mount.Source
+		case "volume":
+			iv.SourceVolume = mount.Source
+		default:
+			log.Printf("Ignoring %s mount %s for task %s", mount.Type, mount.Target, t.Name)
+			continue
+		}
+		response = append(response, iv)
+	}
+	for _, volume := range t.Config.Volumes {
+		response = append(response, parseVolume(volume))
+	}
+	for _, vm := range t.VolumeMounts {
+		response = append(response, transform.IntermediateVolume{
+			SourceVolume: g.Volumes[vm.Volume].Source,
+			Container:    vm.Destination,
+			ReadOnly:     vm.ReadOnly || g.Volumes[vm.Volume].ReadOnly,
+		})
+	}
+	if len(response) > 0 {
+		sort.Sort(response)
+		return &response
+	}
+	return nil
+}
+
+// emitVolumes converts volumes into task group host volumes for named volumes,
+// and docker driver mounts for host paths and anonymous volumes
+func (g *TaskGroup) emitVolumes(t *Task, vols *transform.IntermediateVolumes) {
+	if vols == nil {
+		return
+	}
+	for _, volume := range *vols {
+		if name := volume.NamedVolume(); len(name) > 0 {
+			if g.Volumes == nil {
+				g.Volumes = map[string]VolumeRequest{}
+			}
+			g.Volumes[name] = VolumeRequest{Name: name, Type: "host", Source: name}
+			t.VolumeMounts = append(t.VolumeMounts, VolumeMount{
+				Volume:      name,
+				Destination: volume.Container,
+				ReadOnly:    volume.ReadOnly,
+			})
+			continue
+		}
+		mount := Mount{Type: "volume", Target: volume.Container, ReadOnly: volume.ReadOnly}
+		if len(volume.Host) > 0 {
+			mount.Type = "bind"
+			mount.Source = volume.Host
+		}
+		t.Config.Mounts = append(t.Config.Mounts, mount)
+	}
+}
+
+// ingestTask converts a docker driver task into an intermediate container
+func (g TaskGroup) ingestTask(t Task) transform.Container {
+	ir := transform.Container{}
+	if len(t.Config.Command) > 0 {
+		ir.Command = append([]string{t.Config.Command}, t.Config.Args...)
+	} else {
+		// Without a command, args replace the image's command
+		ir.Command = t.Config.Args
+	}
+	if t.Resources != nil {
+		// Nomad's docker driver uses the task's CPU MHz as its cpu shares
+		ir.CPU = t.Resources.CPU
+		ir.Memory = t.Resources.MemoryMB << 20
+	}
+	ir.DNS = t.Config.DNSServers
+	ir.Domain = t.Config.DNSSearchDomains
+	ir.Entrypoint = t.Config.Entrypoint
+	if len(t.Env) > 0 {
+		ir.Environment = t.Env
+	}
+	for _, artifact := range t.Artifacts {
+		ir.Fetch = append(ir.Fetch, &transform.Fetch{URI: artifact.GetterSource})
+	}
+	ir.Hostname = t.Config.Hostname
+	ir.Image = t.Config.Image
+	for _, labels := range t.Config.Labels {
+		if ir.Labels == nil {
+			ir.Labels = map[string]string{}
+		}
+		for k, v := range labels {
+			ir.Labels[k] = v
+		}
+	}
+	if t.Config.Logging != nil {
+		ir.Logging = &transform.Logging{Driver: t.Config.Logging.Type, Options: map[string]string{}}
+		for _, config := range t.Config.Logging.Config {
+			for k, v := range config {
+				ir.Logging.Options[k] = v
+			}
+		}
+	}
+	ir.Name = t.Name
+	ir.NetworkMode = t.Config.NetworkMode
+	for _, network := range g.Networks {
+		if network.Mode == "host" {
+			ir.NetworkMode = "host"
+		}
+	}
+	ir.Pid = t.Config.PidMode
+	ir.PortMappings = g.ingestPortMappings(t)
+	ir.Privileged = t.Config.Privileged
+	if t.Config.ForcePull {
+		ir.PullImagePolicy = "always"
+	}
+	ir.StopSignal = t.KillSignal
+	ir.User = t.User
+	ir.Volumes = g.ingestVolumes(t)
+	ir.WorkDir = t.Config.WorkDir
+	return ir
+}
+
+// emitTask converts an intermediate container into a docker driver task
+func (g *TaskGroup) emitTask(container transform.Container) Task {
+	t := Task{
+		Name:       container.Name,
+		Driver:     "docker",
+		User:       container.User,
+		KillSignal: container.StopSignal,
+		Config: DockerConfig{
+			Image:            container.Image,
+			DNSServers:       container.DNS,
+			DNSSearchDomains: container.Domain,
+			ForcePull:        container.PullImagePolicy == "always",
+			Hostname:         container.Hostname,
+			NetworkMode:      container.NetworkMode,
+			PidMode:          container.Pid,
+			Privileged:       container.Privileged,
+			WorkDir:          container.WorkDir,
+		},
+	}
+	if args := container.CommandArgs(); len(args) > 0 {
+		t.Config.Command = args[0]
+		t.Config.Args = args[1:]
+	}
+	t.Config.Entrypoint = container.Entrypoint
+	if len(container.Environment) > 0 {
+		t.Env = container.Environment
+	}
+	if len(container.Labels) > 0 {
+		t.Config.Labels = []map[string]string{container.Labels}
+	}
+	if container.Logging != nil {
+		t.Config.Logging = &Logging{Type: container.Logging.Driver}
+		if len(container.Logging.Options) > 0 {
+			t.Config.Logging.Config = []map[string]string{container.Logging.Options}
+		}
+	}
+	if container.CPU > 0 || container.Memory > 0 {
+		t.Resources = &Resources{CPU: container.CPU, MemoryMB: container.Memory >> 20}
+	}
+	for _, fetch := range container.Fetch {
+		if fetch != nil {
+			t.Artifacts = append(t.Artifacts, Artifact{GetterSource: fetch.URI})
+		}
+	}
+	for _, dep := range container.Dependencies() {
+		log.Printf("Ignoring dependency on %s for container %s, tasks in a group share its network", dep, container.Name)
+	}
+	t.Config.Ports = g.emitPortMappings(container)
+	g.emitVolumes(&t, container.Volumes)
+	return t
+}
+
+// podName returns the pod's name, falling back to its first container's name
+func podName(input *transform.PodData) string {
+	if len(input.Name) > 0 {
+		return input.Name
+	}
+	for _, container := range *input.Containers {
+		return container.Name
+	}
+	return "job"
+}
+
+// emitJob converts a pod into a Nomad job with a single task group
+func emitJob(input *transform.PodData) *Job {
+	name := podName(input)
+
+	count := input.Replicas
+	for _, container := range *input.Containers {
+		if container.Replicas > count {
+			count = container.Replicas
+		}
+	}
+	if count == 0 {
+		count = 1
+	}
+
+	group := TaskGroup{Name: name, Count: count}
+	for _, container := range *input.Containers {
+		if input.HostNetwork {
+			container.NetworkMode = "host"
+		}
+		if input.HostPID {
+			container.Pid = "host"
+		}
+		group.Tasks = append(group.Tasks, group.emitTask(container))
+	}
+
+	job := &Job{
+		ID:          name,
+		Name:        name,
+		Type:        "service",
+		Datacenters: []string{"dc1"},
+		TaskGroups:  []TaskGroup{group},
+	}
+	if len(input.GlobalLabels) > 0 {
+		job.Meta = input.GlobalLabels
+	}
+	return job
+}
+
+// IngestContainers satisfies InputFormat so Nomad JSON jobs can be ingested
+func (j Job) IngestContainers(input io.ReadCloser) (*transform.PodData, error) {
+
+	body, err := ioutil.ReadAll(input)
+	defer input.Close()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	spec := jobSpec{Job: &j}
+	err = json.Unmarshal(body, &spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(j.TaskGroups) == 0 {
+		// A bare job without the {"Job": ...} envelope
+		err = json.Unmarshal(body, &j)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	outputPod := transform.PodData{Name: j.Name}
+	if len(outputPod.Name) == 0 {
+		outputPod.Name = j.ID
+	}
+	if len(j.Meta) > 0 {
+		outputPod.GlobalLabels = j.Meta
+	}
+
+	containers := transform.Containers{}
+	for i, group := range j.TaskGroups {
+		if i > 0 {
+			log.Printf("Ignoring task group %s, only the first task group is ingested", group.Name)
+			continue
+		}
+		outputPod.Replicas = group.Count
+		for _, task := range group.Tasks {
+			if task.Driver != "docker" {
+				log.Printf("Ignoring task %s with unsupported driver %s", task.Name, task.Driver)
+				continue
+			}
+			containers = append(containers, group.ingestTask(task))
+		}
+	}
+	sort.Sort(containers)
+	outputPod.Containers = &containers
+
+	return &outputPod, nil
+}
+
+// EmitContainers satisfies OutputFormat so Nomad JSON jobs can be emitted
+func (j Job) EmitContainers(input *transform.PodData) ([]byte, error) {
+	return json.MarshalIndent(jobSpec{Job: emitJob(input)}, "", "    ")
+}
@@ -0,0 +1,141 @@
+package nomad
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func ingestCompose(t *testing.T) *transform.PodData {
+	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := compose.DockerCompose{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+	bp.Name = "app"
+	bp.Replicas = 3
+	return bp
+}
+
+func compareFixture(t *testing.T, got []byte, fixture string) {
+	expected, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitContainers(t *testing.T) {
+	got, err := Job{}.EmitContainers(ingestCompose(t))
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	compareFixture(t, got, "./test_fixtures/job.json")
+}
+
+func TestEmitHCL(t *testing.T) {
+	got, err := HCL{}.EmitContainers(ingestCompose(t))
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	compareFixture(t, got, "./test_fixtures/job.nomad")
+}
+
+func TestIngestContainers(t *testing.T) {
+	f, err := os.Open("./test_fixtures/job.json")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := Job{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, "app", bp.Name)
+	assert.Equal(t, 3, bp.Replicas)
+	assert.Len(t, *bp.Containers, 2)
+
+	web := (*bp.Containers)[1]
+	assert.Equal(t, []string{"serve", "--port", "8080"}, web.Command)
+	assert.Equal(t, 500, web.CPU)
+	assert.Equal(t, 134217728, web.Memory)
+	assert.Equal(t, &transform.PortMappings{
+		{Name: "port_8080", HostPort: 8080, ContainerPort: 8080},
+		{Name: "port_9090", ContainerPort: 9090},
+	}, web.PortMappings)
+
+	db := (*bp.Containers)[0]
+	assert.Equal(t, &transform.IntermediateVolumes{
+		{Host: "/etc/localtime", Container: "/etc/localtime", ReadOnly: true},
+		{Container: "/tmp"},
+		{SourceVolume: "pgdata", Container: "/var/lib/postgresql/data"},
+	}, db.Volumes)
+
+	got, err := Job{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+	compareFixture(t, got, "./test_fixtures/job.json")
+}
+
+func TestIngestBareJob(t *testing.T) {
+	body := `{"ID": "cache", "TaskGroups": [{"Name": "cache", "Count": 1, "Tasks": [
+		{"Name": "redis", "Driver": "docker", "Config": {"image": "redis"}},
+		{"Name": "backup", "Driver": "exec", "Config": {}}
+	]}]}`
+
+	bp, err := Job{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, "cache", bp.Name)
+	assert.Len(t, *bp.Containers, 1)
+	assert.Equal(t, "redis", (*bp.Containers)[0].Image)
+}
+
+func TestIngestArgsWithoutCommand(t *testing.T) {
+	body := `{"ID": "cache", "TaskGroups": [{"Name": "cache", "Tasks": [
+		{"Name": "redis", "Driver": "docker", "Config": {"image": "redis", "args": ["--port", "7000"]}}
+	]}]}`
+
+	bp, err := Job{}.IngestContainers(ioutil.NopCloser(bytes.NewBufferString(body)))
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	assert.Equal(t, []string{"--port", "7000"}, (*bp.Containers)[0].Command)
+}
+
+func TestEmitPortLabels(t *testing.T) {
+	g := &TaskGroup{}
+	labels := g.emitPortMappings(transform.Container{Name: "web", PortMappings: &transform.PortMappings{
+		{Name: "http", HostPort: 8080, ContainerPort: 80},
+		{Name: "http", HostPort: 8081, ContainerPort: 80},
+	}})
+	labels = append(labels, g.emitPortMappings(transform.Container{Name: "proxy", PortMappings: &transform.PortMappings{
+		{Name: "http", HostPort: 8080, ContainerPort: 80},
+	}})...)
+
+	assert.Equal(t, []string{"http", "http_2", "http"}, labels)
+	assert.Equal(t, []Port{
+		{Label: "http", Value: 8080, To: 80},
+		{Label: "http_2", Value: 8081, To: 80},
+	}, g.Networks[0].ReservedPorts)
+}
@@ -0,0 +1,161 @@
+package nomad
+
+const jobTemplate = `job {{ quote .ID }} {
+  datacenters = {{ list .Datacenters }}
+  type = {{ quote .Type }}
+{{- with .Meta }}
+
+  meta = {{ object . 2 }}
+{{- end }}
+{{- range .TaskGroups }}
+
+  group {{ quote .Name }} {
+    count = {{ .Count }}
+{{- range .Networks }}
+
+    network {
+{{- with .Mode }}
+      mode = {{ quote . }}
+{{- end }}
+{{- range .ReservedPorts }}
+      port {{ quote .Label }} {
+        static = {{ .Value }}
+{{- with .To }}
+        to = {{ . }}
+{{- end }}
+      }
+{{- end }}
+{{- range .DynamicPorts }}
+      port {{ quote .Label }} {
+        to = {{ .To }}
+      }
+{{- end }}
+    }
+{{- end }}
+{{- range .Volumes }}
+
+    volume {{ quote .Name }} {
+      type = {{ quote .Type }}
+      source = {{ quote .Source }}
+{{- if .ReadOnly }}
+      read_only = true
+{{- end }}
+    }
+{{- end }}
+{{- range .Tasks }}
+
+    task {{ quote .Name }} {
+      driver = {{ quote .Driver }}
+{{- with .User }}
+      user = {{ quote . }}
+{{- end }}
+{{- with .KillSignal }}
+      kill_signal = {{ quote . }}
+{{- end }}
+{{- with .Config }}
+
+      config {
+        image = {{ quote .Image }}
+{{- with .Command }}
+        command = {{ quote . }}
+{{- end }}
+{{- with .Args }}
+        args = {{ list . }}
+{{- end }}
+{{- with .Entrypoint }}
+        entrypoint = {{ list . }}
+{{- end }}
+{{- with .DNSServers }}
+        dns_servers = {{ list . }}
+{{- end }}
+{{- with .DNSSearchDomains }}
+        dns_search_domains = {{ list . }}
+{{- end }}
+{{- if .ForcePull }}
+        force_pull = true
+{{- end }}
+{{- with .Hostname }}
+        hostname = {{ quote . }}
+{{- end }}
+{{- with .Labels }}
+        labels = {{ objects . 8 }}
+{{- end }}
+{{- with .NetworkMode }}
+        network_mode = {{ quote . }}
+{{- end }}
+{{- with .PidMode }}
+        pid_mode = {{ quote . }}
+{{- end }}
+{{- with .Ports }}
+        ports = {{ list . }}
+{{- end }}
+{{- if .Privileged }}
+        privileged = true
+{{- end }}
+{{- with .Volumes }}
+        volumes = {{ list . }}
+{{- end }}
+{{- with .WorkDir }}
+        work_dir = {{ quote . }}
+{{- end }}
+{{- with .Logging }}
+
+        logging {
+          type = {{ quote .Type }}
+{{- with .Config }}
+          config = {{ objects . 10 }}
+{{- end }}
+        }
+{{- end }}
+{{- range .Mounts }}
+
+        mount {
+          type = {{ quote .Type }}
+          target = {{ quote .Target }}
+{{- with .Source }}
+          source = {{ quote . }}
+{{- end }}
+{{- if .ReadOnly }}
+          readonly = true
+{{- end }}
+        }
+{{- end }}
+      }
+{{- end }}
+{{- with .Env }}
+
+      env = {{ object . 6 }}
+{{- end }}
+{{- range .VolumeMounts }}
+
+      volume_mount {
+        volume = {{ quote .Volume }}
+        destination = {{ quote .Destination }}
+{{- if .ReadOnly }}
+        read_only = true
+{{- end }}
+      }
+{{- end }}
+{{- range .Artifacts }}
+
+      artifact {
+        source = {{ quote .GetterSource }}
+      }
+{{- end }}
+{{- with .Resources }}
+
+      resources {
+{{- with .CPU }}
+        cpu = {{ . }}
+{{- end }}
+{{- with .MemoryMB }}
+        memory = {{ . }}
+{{- end }}
+      }
+{{- end }}
+    }
+{{- end }}
+  }
+{{- end }}
+}
+`
@@ -0,0 +1,2 @@
+// Package oci for emitting containers as OCI runtime bundles
+package oci
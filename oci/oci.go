@@ -0,0 +1,319 @@
+package oci
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+const (
+	ociVersion  = "1.0.2"
+	defaultPath = "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// defaultCapabilities are the capabilities runc spec grants a container
+var defaultCapabilities = []string{"CAP_AUDIT_WRITE", "CAP_KILL", "CAP_NET_BIND_SERVICE"}
+
+// User is the user a container's process runs as
+type User struct {
+	UID uint32 `json:"uid"`
+	GID uint32 `json:"gid"`
+}
+
+// Capabilities are the capability sets of a container's process
+type Capabilities struct {
+	Bounding  []string `json:"bounding"`
+	Effective []string `json:"effective"`
+	Permitted []string `json:"permitted"`
+	Ambient   []string `json:"ambient"`
+}
+
+// Rlimit is a resource limit of a container's process
+type Rlimit struct {
+	Type string `json:"type"`
+	Hard uint64 `json:"hard"`
+	Soft uint64 `json:"soft"`
+}
+
+// Process is the process a container runs
+type Process struct {
+	Terminal        bool          `json:"terminal"`
+	User            User          `json:"user"`
+	Args            []string      `json:"args"`
+	Env             []string      `json:"env"`
+	Cwd             string        `json:"cwd"`
+	Capabilities    *Capabilities `json:"capabilities"`
+	Rlimits         []Rlimit      `json:"rlimits"`
+	NoNewPrivileges bool          `json:"noNewPrivileges"`
+}
+
+// Root is the container's root filesystem, relative to the bundle
+type Root struct {
+	Path     string `json:"path"`
+	Readonly bool   `json:"readonly"`
+}
+
+// Mount is a filesystem mounted into a container
+type Mount struct {
+	Destination string   `json:"destination"`
+	Type        string   `json:"type"`
+	Source      string   `json:"source"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// defaultMounts are the filesystems runc spec mounts in every container
+var defaultMounts = []Mount{
+	{Destination: "/proc", Type: "proc", Source: "proc"},
+	{Destination: "/dev", Type: "tmpfs", Source: "tmpfs", Options: []string{"nosuid", "strictatime", "mode=755", "size=65536k"}},
+	{Destination: "/dev/pts", Type: "devpts", Source: "devpts", Options: []string{"nosuid", "noexec", "newinstance", "ptmxmode=0666", "mode=0620", "gid=5"}},
+	{Destination: "/dev/shm", Type: "tmpfs", Source: "shm", Options: []string{"nosuid", "noexec", "nodev", "mode=1777", "size=65536k"}},
+	{Destination: "/dev/mqueue", Type: "mqueue", Source: "mqueue", Options: []string{"nosuid", "noexec", "nodev"}},
+	{Destination: "/sys", Type: "sysfs", Source: "sysfs", Options: []string{"nosuid", "noexec", "nodev", "ro"}},
+	{Destination: "/sys/fs/cgroup", Type: "cgroup", Source: "cgroup", Options: []string{"nosuid", "noexec", "nodev", "relatime", "ro"}},
+}
+
+// Memory is a container's memory limits
+type Memory struct {
+	Limit int64 `json:"limit"`
+}
+
+// CPU is a container's cpu limits
+type CPU struct {
+	Shares uint64 `json:"shares"`
+}
+
+// Resources are the cgroup limits of a container
+type Resources struct {
+	Memory *Memory `json:"memory,omitempty"`
+	CPU    *CPU    `json:"cpu,omitempty"`
+}
+
+// Namespace is a namespace a container is created in
+type Namespace struct {
+	Type string `json:"type"`
+}
+
+// Linux is the linux specific configuration of a container
+type Linux struct {
+	Resources     *Resources  `json:"resources,omitempty"`
+	Namespaces    []Namespace `json:"namespaces"`
+	MaskedPaths   []string    `json:"maskedPaths"`
+	ReadonlyPaths []string    `json:"readonlyPaths"`
+}
+
+// Spec is an OCI runtime-spec config.json
+type Spec struct {
+	OCIVersion  string            `json:"ociVersion"`
+	Process     *Process          `json:"process"`
+	Root        *Root             `json:"root"`
+	Hostname    string            `json:"hostname"`
+	Mounts      []Mount           `json:"mounts"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Linux       *Linux            `json:"linux"`
+}
+
+// parseUser converts a docker user, uid[:gid], into numeric ids. OCI bundles
+// have no passwd lookup, so user and group names other than root are errors
+func parseUser(user string) (User, error) {
+	response := User{}
+	if len(user) == 0 {
+		return response, nil
+	}
+	ids := []*uint32{&response.UID, &response.GID}
+	for i, part := range strings.SplitN(user, ":", 2) {
+		if part == "root" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return response, fmt.Errorf("user %s must be numeric in an OCI bundle", user)
+		}
+		*ids[i] = uint32(id)
+	}
+	return response, nil
+}
+
+// emitEnv converts the container's environment, adding a default PATH since
+// the image's environment is not inherited
+func emitEnv(environment map[string]string) []string {
+	env := []string{}
+	if _, ok := environment["PATH"]; !ok {
+		env = append(env, defaultPath)
+	}
+	keys := []string{}
+	for k := range environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+environment[k])
+	}
+	return env
+}
+
+// emitMounts converts the container's host path volumes into bind mounts
+func emitMounts(container transform.Container) []Mount {
+	mounts := append([]Mount{}, defaultMounts...)
+	if container.Volumes == nil {
+		return mounts
+	}
+	for _, volume := range *container.Volumes {
+		if len(volume.Host) == 0 || len(volume.NamedVolume()) > 0 {
+			log.Printf("OCI bundles have no volumes, skipping volume %s for container %s", volume.Container, container.Name)
+			continue
+		}
+		mode := "rw"
+		if volume.ReadOnly {
+			mode = "ro"
+		}
+		mounts = append(mounts, Mount{
+			Destination: volume.Container,
+			Type:        "bind",
+			Source:      volume.Host,
+			Options:     []string{"rbind", "rprivate", mode},
+		})
+	}
+	return mounts
+}
+
+// emitNamespaces returns the namespaces to create, sharing the host's network
+// and pid namespaces when the pod or container does
+func emitNamespaces(pod *transform.PodData, container transform.Container) []Namespace {
+	namespaces := []Namespace{}
+	if !pod.HostPID && container.Pid != "host" {
+		namespaces = append(namespaces, Namespace{Type: "pid"})
+	}
+	if !pod.HostNetwork && container.NetworkMode != "host" {
+		namespaces = append(namespaces, Namespace{Type: "network"})
+	}
+	return append(namespaces, Namespace{Type: "ipc"}, Namespace{Type: "uts"}, Namespace{Type: "mount"})
+}
+
+func emitResources(container transform.Container) *Resources {
+	if container.Memory == 0 && container.CPU == 0 {
+		return nil
+	}
+	resources := &Resources{}
+	if container.Memory > 0 {
+		resources.Memory = &Memory{Limit: int64(container.Memory)}
+	}
+	if container.CPU > 0 {
+		resources.CPU = &CPU{Shares: uint64(container.CPU)}
+	}
+	return resources
+}
+
+// emitSpec converts an intermediate container into an OCI runtime spec
+func emitSpec(pod *transform.PodData, container transform.Container) (*Spec, error) {
+	args := append(append([]string{}, container.Entrypoint...), container.CommandArgs()...)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("container %s has no entrypoint or command, which OCI bundles do not read from the image", container.Name)
+	}
+
+	user, err := parseUser(container.User)
+	if err != nil {
+		return nil, fmt.Errorf("container %s: %s", container.Name, err)
+	}
+
+	cwd := container.WorkDir
+	if len(cwd) == 0 {
+		cwd = "/"
+	}
+	hostname := container.Hostname
+	if len(hostname) == 0 {
+		hostname = container.Name
+	}
+
+	if container.PortMappings != nil || len(container.DNS) > 0 {
+		log.Printf("OCI bundles do not configure networking, skipping ports and DNS for container %s", container.Name)
+	}
+	if container.Privileged {
+		log.Printf("Privileged containers are not supported, container %s keeps the default capabilities", container.Name)
+	}
+
+	return &Spec{
+		OCIVersion: ociVersion,
+		Process: &Process{
+			User: user,
+			Args: args,
+			Env:  emitEnv(container.Environment),
+			Cwd:  cwd,
+			Capabilities: &Capabilities{
+				Bounding:  defaultCapabilities,
+				Effective: defaultCapabilities,
+				Permitted: defaultCapabilities,
+				Ambient:   defaultCapabilities,
+			},
+			Rlimits:         []Rlimit{{Type: "RLIMIT_NOFILE", Hard: 1024, Soft: 1024}},
+			NoNewPrivileges: true,
+		},
+		Root:        &Root{Path: "rootfs"},
+		Hostname:    hostname,
+		Mounts:      emitMounts(container),
+		Annotations: container.Labels,
+		Linux: &Linux{
+			Resources:  emitResources(container),
+			Namespaces: emitNamespaces(pod, container),
+			MaskedPaths: []string{
+				"/proc/acpi", "/proc/asound", "/proc/kcore", "/proc/keys", "/proc/latency_stats",
+				"/proc/timer_list", "/proc/timer_stats", "/proc/sched_debug", "/sys/firmware", "/proc/scsi",
+			},
+			ReadonlyPaths: []string{
+				"/proc/bus", "/proc/fs", "/proc/irq", "/proc/sys", "/proc/sysrq-trigger",
+			},
+		},
+	}, nil
+}
+
+// bundlePath returns the path of a container's config.json, within its bundle directory
+func bundlePath(name string) string {
+	return name + "/config.json"
+}
+
+// Bundle represents a set of OCI runtime bundles, one per container. The
+// rootfs of each bundle must be unpacked from the container's image
+// separately. It implements OutputFormat and FileOutputFormat
+type Bundle struct{}
+
+// EmitFiles satisfies FileOutputFormat so each bundle's config.json can be
+// written to its own directory
+func (b Bundle) EmitFiles(input *transform.PodData) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	for _, container := range *input.Containers {
+		spec, err := emitSpec(input, container)
+		if err != nil {
+			return nil, err
+		}
+		body, err := json.MarshalIndent(spec, "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		files[bundlePath(container.Name)] = body
+	}
+	return files, nil
+}
+
+// EmitContainers satisfies OutputFormat so OCI runtime specs can be emitted
+func (b Bundle) EmitContainers(input *transform.PodData) ([]byte, error) {
+	files, err := b.EmitFiles(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	for i, container := range *input.Containers {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString("######## " + bundlePath(container.Name) + " ########\n")
+		buffer.Write(files[bundlePath(container.Name)])
+		buffer.WriteString("\n")
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package oci
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestEmitContainers(t *testing.T) {
+	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := compose.DockerCompose{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := Bundle{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/bundle.out")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitFiles(t *testing.T) {
+	bp := &transform.PodData{
+		HostNetwork: true,
+		HostPID:     true,
+		Containers:  &transform.Containers{{Name: "cache", Image: "redis", Command: []string{"redis-server"}}},
+	}
+
+	files, err := Bundle{}.EmitFiles(bp)
+	if err != nil {
+		t.Errorf("Failed to emit files: %s", err)
+	}
+
+	assert.Len(t, files, 1)
+	assert.Contains(t, string(files["cache/config.json"]), `"namespaces": [
+            {
+                "type": "ipc"
+            },`)
+
+	bp.Containers = &transform.Containers{{Name: "cache", Image: "redis"}}
+	_, err = Bundle{}.EmitFiles(bp)
+	assert.NotNil(t, err)
+}
+
+func TestParseUser(t *testing.T) {
+	user, err := parseUser("1000:50")
+	assert.Nil(t, err)
+	assert.Equal(t, User{UID: 1000, GID: 50}, user)
+
+	user, err = parseUser("root")
+	assert.Nil(t, err)
+	assert.Equal(t, User{}, user)
+
+	_, err = parseUser("nginx")
+	assert.NotNil(t, err)
+}
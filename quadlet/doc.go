@@ -0,0 +1,2 @@
+// Package quadlet is for emitting Podman Quadlet unit files
+package quadlet
@@ -0,0 +1,254 @@
+package quadlet
+
+import (
+	"bytes"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/micahhausler/container-tx/script"
+	"github.com/micahhausler/container-tx/systemd"
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// Entry is a single key and value in a Quadlet unit section
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// Section is a named section of a Quadlet unit file
+type Section struct {
+	Name    string
+	Entries []Entry
+}
+
+func (s *Section) add(key, value string) {
+	s.Entries = append(s.Entries, Entry{Key: key, Value: value})
+}
+
+// File is a Quadlet unit file
+type File struct {
+	Name     string
+	Sections []*Section
+}
+
+// Bytes renders a Quadlet unit file
+func (f File) Bytes() []byte {
+	var buffer bytes.Buffer
+	for i, section := range f.Sections {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString("[" + section.Name + "]\n")
+		for _, entry := range section.Entries {
+			buffer.WriteString(entry.Key + "=" + entry.Value + "\n")
+		}
+	}
+	return buffer.Bytes()
+}
+
+// emitVolume converts a volume into a Quadlet Volume value, referencing a
+// .volume file for named volumes
+func emitVolume(volume transform.IntermediateVolume) string {
+	parts := []string{volume.Host, volume.Container}
+	if name := volume.NamedVolume(); len(name) > 0 {
+		parts[0] = name + ".volume"
+	}
+	if volume.ReadOnly {
+		parts = append(parts, "ro")
+	}
+	return strings.Trim(strings.Join(parts, ":"), ":")
+}
+
+// quoteEscaper escapes the characters Quadlet unquotes in a quoted value
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+// quoteValue quotes a value for a Quadlet key such as Environment=. Quadlet
+// passes these values to podman as written, so unlike Exec= they have no
+// specifiers or variables to escape
+func quoteValue(value string) string {
+	if len(value) == 0 || strings.ContainsAny(value, " \t\n\"'\\") {
+		return `"` + quoteEscaper.Replace(value) + `"`
+	}
+	return value
+}
+
+// optionValue splits a docker run option into its flag and value
+func optionValue(option script.Option) (string, string) {
+	if len(option) > 1 {
+		return option[0], option[1]
+	}
+	parts := strings.SplitN(option[0], "=", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
+
+// emitContainer converts an intermediate container into a Quadlet .container
+// file. Options without a Quadlet key are passed through as PodmanArgs
+func emitContainer(pod string, container transform.Container) File {
+	unit := &Section{Name: "Unit"}
+	unit.add("Description", container.Name+" container")
+	if deps := container.Dependencies(); len(deps) > 0 {
+		services := []string{}
+		for _, dep := range deps {
+			services = append(services, dep+".service")
+		}
+		unit.add("Requires", strings.Join(services, " "))
+		unit.add("After", strings.Join(services, " "))
+	}
+
+	service := &Section{Name: "Service"}
+	section := &Section{Name: "Container"}
+	section.add("ContainerName", container.Name)
+	section.add("Image", container.Image)
+	section.add("Pod", pod+".pod")
+	if args := container.RunArgs(); len(args) > 0 {
+		section.add("Exec", systemd.EscapeArgs(args))
+	}
+	for _, option := range script.RunOptions(container) {
+		flag, value := optionValue(option)
+		switch flag {
+		case "--env":
+			section.add("Environment", quoteValue(value))
+		case "--label":
+			section.add("Label", quoteValue(value))
+		case "--user":
+			section.add("User", value)
+		case "--workdir":
+			section.add("WorkingDir", value)
+		case "--name", "--publish", "--volume":
+			// Set from the container's name, or on the pod and from its volumes
+		case "--net":
+			// Host networking is set on the pod
+			if value != "host" {
+				log.Printf("Ignoring %s for container %s, containers in a pod share its network", flag, container.Name)
+			}
+		case "--restart":
+			service.add("Restart", systemd.RestartPolicy(value))
+		case "--link", "--net-alias":
+			log.Printf("Ignoring %s for container %s, containers in a pod share its network", flag, container.Name)
+		default:
+			section.add("PodmanArgs", systemd.EscapeOption(option))
+		}
+	}
+	if container.Volumes != nil {
+		for _, volume := range *container.Volumes {
+			section.add("Volume", emitVolume(volume))
+		}
+	}
+
+	sections := []*Section{unit, section}
+	if len(service.Entries) > 0 {
+		sections = append(sections, service)
+	}
+	return File{
+		Name:     container.Name + ".container",
+		Sections: sections,
+	}
+}
+
+// emitPod creates a Quadlet .pod file. Podman only publishes ports on the
+// pod, so every container's port mappings are published here
+func emitPod(name string, input *transform.PodData) File {
+	section := &Section{Name: "Pod"}
+	section.add("PodName", name)
+	hostNetwork := input.HostNetwork
+	for _, container := range *input.Containers {
+		if container.NetworkMode == "host" {
+			hostNetwork = true
+		}
+		for _, option := range script.RunOptions(container) {
+			if flag, value := optionValue(option); flag == "--publish" {
+				section.add("PublishPort", value)
+			}
+		}
+	}
+	if hostNetwork {
+		section.add("Network", "host")
+	}
+
+	install := &Section{Name: "Install"}
+	install.add("WantedBy", "default.target")
+
+	return File{
+		Name:     name + ".pod",
+		Sections: []*Section{section, install},
+	}
+}
+
+// emitVolumes creates a Quadlet .volume file for each named volume
+func emitVolumes(containers *transform.Containers) []File {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, container := range *containers {
+		if container.Volumes == nil {
+			continue
+		}
+		for _, volume := range *container.Volumes {
+			if name := volume.NamedVolume(); len(name) > 0 && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	files := []File{}
+	for _, name := range names {
+		section := &Section{Name: "Volume"}
+		section.add("VolumeName", name)
+		files = append(files, File{Name: name + ".volume", Sections: []*Section{section}})
+	}
+	return files
+}
+
+// podName returns the pod's name, falling back to its first container's name
+func podName(input *transform.PodData) string {
+	if len(input.Name) > 0 {
+		return input.Name
+	}
+	for _, container := range *input.Containers {
+		return container.Name
+	}
+	return "pod"
+}
+
+// Pod represents a Podman Quadlet pod, with a .container file for each
+// container and a .volume file for each named volume. It implements
+// OutputFormat and FileOutputFormat
+type Pod struct{}
+
+// emitFiles converts a pod into Quadlet files, pod first
+func (p Pod) emitFiles(input *transform.PodData) []File {
+	name := podName(input)
+	files := []File{emitPod(name, input)}
+	for _, container := range *input.Containers {
+		files = append(files, emitContainer(name, container))
+	}
+	return append(files, emitVolumes(input.Containers)...)
+}
+
+// EmitFiles satisfies FileOutputFormat so Quadlet files can be written to a directory
+func (p Pod) EmitFiles(input *transform.PodData) (map[string][]byte, error) {
+	response := map[string][]byte{}
+	for _, file := range p.emitFiles(input) {
+		response[file.Name] = file.Bytes()
+	}
+	return response, nil
+}
+
+// EmitContainers satisfies OutputFormat so Quadlet files can be emitted
+func (p Pod) EmitContainers(input *transform.PodData) ([]byte, error) {
+	var buffer bytes.Buffer
+	for i, file := range p.emitFiles(input) {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString("######## " + file.Name + " ########\n")
+		buffer.Write(file.Bytes())
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,68 @@
+package quadlet
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestEmitContainers(t *testing.T) {
+	cf := compose.DockerCompose{}
+
+	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := cf.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+	bp.Name = "app"
+
+	got, err := Pod{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/compose.out")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitFiles(t *testing.T) {
+	bp := &transform.PodData{
+		Containers: &transform.Containers{{
+			Name:        "cache",
+			Image:       "redis",
+			NetworkMode: "host",
+			Volumes: &transform.IntermediateVolumes{
+				{Container: "/data", SourceVolume: "redis-data"},
+				{Container: "/tmp"},
+			},
+		}},
+	}
+
+	files, err := Pod{}.EmitFiles(bp)
+	if err != nil {
+		t.Errorf("Failed to emit files: %s", err)
+	}
+
+	assert.Len(t, files, 3)
+	assert.Contains(t, string(files["cache.pod"]), "PodName=cache\nNetwork=host\n")
+	assert.Contains(t, string(files["cache.container"]), "Volume=redis-data.volume:/data\nVolume=/tmp\n")
+	assert.Equal(t, "[Volume]\nVolumeName=redis-data\n", string(files["redis-data.volume"]))
+}
@@ -1,2 +1,2 @@
-// Package script for emitting docker containers to the command line
+// Package script for ingesting and emitting docker run commands
 package script
@@ -0,0 +1,190 @@
+package script
+
+import (
+	"log"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// engineFlags maps docker run flags to the spelling of other engines. An
+// empty spelling means the engine has no such flag
+var engineFlags = map[string]map[string]string{
+	"podman": {
+		"--link":      "",
+		"--net":       "--network",
+		"--net-alias": "--network-alias",
+	},
+	"nerdctl": {
+		"--expose":    "",
+		"--link":      "",
+		"--net":       "--network",
+		"--net-alias": "",
+	},
+}
+
+// engineLogDrivers are the logging drivers each engine other than docker supports
+var engineLogDrivers = map[string]map[string]bool{
+	"podman":  {"journald": true, "json-file": true, "k8s-file": true, "none": true, "passthrough": true},
+	"nerdctl": {"fluentd": true, "journald": true, "json-file": true, "none": true, "syslog": true},
+}
+
+// podFlags are the options podman sets on a pod rather than on the
+// containers in it, since they share the pod's network namespace
+var podFlags = map[string]bool{
+	"--dns":        true,
+	"--dns-search": true,
+	"--expose":     true,
+	"--hostname":   true,
+	"--link":       true,
+	"--net":        true,
+	"--net-alias":  true,
+	"--publish":    true,
+}
+
+// flagName returns the flag of an option, with any =value removed
+func flagName(option Option) string {
+	return strings.SplitN(option[0], "=", 2)[0]
+}
+
+// rename returns an option with its flag respelled
+func rename(option Option, flag string) Option {
+	response := append(Option{}, option...)
+	response[0] = flag + strings.TrimPrefix(option[0], flagName(option))
+	return response
+}
+
+// EngineOptions returns the run options of a container for an engine,
+// translating the flags it spells differently and dropping those it lacks
+func EngineOptions(engine string, c transform.Container) []Option {
+	return translateOptions(engine, c, RunOptions(c))
+}
+
+// translateOptions translates a container's run options for an engine
+func translateOptions(engine string, c transform.Container, runOptions []Option) []Option {
+	flags, ok := engineFlags[engine]
+	if !ok {
+		return runOptions
+	}
+	dropLogging := c.Logging != nil && !engineLogDrivers[engine][c.Logging.Driver]
+	if dropLogging {
+		log.Printf("%s does not support the %s log driver, dropping logging for container %s", engine, c.Logging.Driver, c.Name)
+	}
+
+	options := []Option{}
+	for _, option := range runOptions {
+		flag := flagName(option)
+		if dropLogging && (flag == "--log-driver" || flag == "--log-opt") {
+			continue
+		}
+		spelling, ok := flags[flag]
+		switch {
+		case !ok:
+			options = append(options, option)
+		case len(spelling) == 0:
+			log.Printf("%s does not support %s, dropping %s for container %s", engine, flag, strings.Join(option, " "), c.Name)
+		default:
+			options = append(options, rename(option, spelling))
+		}
+	}
+	return options
+}
+
+// Pod is a podman pod, created before the containers which join it
+type Pod struct {
+	Name    string
+	Options []Option
+}
+
+// podName returns the pod's name, falling back to its first container's name
+func podName(input *transform.PodData) string {
+	if len(input.Name) > 0 {
+		return input.Name
+	}
+	for _, container := range *input.Containers {
+		return container.Name
+	}
+	return "pod"
+}
+
+// addOnce appends an option unless an identical one is present
+func addOnce(options []Option, option Option) []Option {
+	for _, existing := range options {
+		if strings.Join(existing, " ") == strings.Join(option, " ") {
+			return options
+		}
+	}
+	return append(options, option)
+}
+
+// emitPod moves the network options of each container onto a podman pod.
+// It returns the pod and the containers' remaining options
+func emitPod(input *transform.PodData) (Pod, map[string][]Option) {
+	pod := Pod{Name: podName(input)}
+	containerOptions := map[string][]Option{}
+	network, hostname := "", ""
+	if input.HostNetwork {
+		network = "host"
+	}
+
+	for _, c := range *input.Containers {
+		options := []Option{}
+		for _, option := range RunOptions(c) {
+			flag := flagName(option)
+			if !podFlags[flag] {
+				options = append(options, option)
+				continue
+			}
+			value := option[len(option)-1]
+			if len(option) == 1 {
+				value = strings.TrimPrefix(option[0], flag+"=")
+			}
+			switch flag {
+			case "--hostname":
+				if len(hostname) > 0 && hostname != value {
+					log.Printf("Pod %s already has hostname %s, dropping hostname %s for container %s", pod.Name, hostname, value, c.Name)
+				} else {
+					hostname = value
+				}
+			case "--net":
+				if len(network) > 0 && network != value {
+					log.Printf("Pod %s already uses network %s, dropping network %s for container %s", pod.Name, network, value, c.Name)
+				} else {
+					network = value
+				}
+			case "--link":
+				log.Printf("Containers in pod %s share localhost, dropping link %s for container %s", pod.Name, value, c.Name)
+			case "--expose":
+				log.Printf("Podman pods cannot expose ports, dropping exposed port %s for container %s", value, c.Name)
+			default:
+				pod.Options = addOnce(pod.Options, option)
+			}
+		}
+		options = translateOptions("podman", c, options)
+		containerOptions[c.Name] = append(options, Option{"--pod", pod.Name})
+	}
+
+	if network == "host" {
+		options := []Option{}
+		for _, option := range pod.Options {
+			if flagName(option) == "--publish" {
+				log.Printf("Pod %s uses the host network, dropping %s", pod.Name, strings.Join(option, " "))
+				continue
+			}
+			options = append(options, option)
+		}
+		pod.Options = options
+	}
+	if len(hostname) > 0 {
+		pod.Options = append(pod.Options, Option{"--hostname=" + hostname})
+	}
+	if len(network) > 0 {
+		pod.Options = append(pod.Options, Option{"--net", network})
+	}
+	for i, option := range pod.Options {
+		if spelling, ok := engineFlags["podman"][flagName(option)]; ok && len(spelling) > 0 {
+			pod.Options[i] = rename(option, spelling)
+		}
+	}
+	return pod, containerOptions
+}
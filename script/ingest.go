@@ -0,0 +1,569 @@
+package script
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// runtimes are the container CLIs whose run commands can be ingested
+var runtimes = map[string]bool{"docker": true, "nerdctl": true, "podman": true}
+
+// runSubcommands are the subcommands that create a container
+var runSubcommands = map[string]bool{"run": true, "create": true}
+
+// globalValueFlags are CLI flags given before the subcommand which take a value
+var globalValueFlags = map[string]bool{"-H": true, "--host": true, "-c": true, "--context": true, "--config": true, "-l": true, "--log-level": true}
+
+// shortFlags maps single letter flags to their long form
+var shortFlags = map[byte]string{
+	'a': "--attach",
+	'c': "--cpu-shares",
+	'd': "--detach",
+	'e': "--env",
+	'h': "--hostname",
+	'i': "--interactive",
+	'l': "--label",
+	'm': "--memory",
+	'P': "--publish-all",
+	'p': "--publish",
+	'q': "--quiet",
+	't': "--tty",
+	'u': "--user",
+	'v': "--volume",
+	'w': "--workdir",
+}
+
+// runFlag applies the value of a docker run flag to a container
+type runFlag func(c *transform.Container, value string) error
+
+// runFlags are the docker run flags which take a value and have an
+// intermediate equivalent
+var runFlags = map[string]runFlag{
+	"--cap-add": func(c *transform.Container, value string) error {
+		c.CapAdd = append(c.CapAdd, value)
+		return nil
+	},
+	"--cap-drop": func(c *transform.Container, value string) error {
+		c.CapDrop = append(c.CapDrop, value)
+		return nil
+	},
+	"--cpu-shares": func(c *transform.Container, value string) (err error) {
+		c.CPU, err = strconv.Atoi(value)
+		return err
+	},
+	"--cpus": func(c *transform.Container, value string) error {
+		cpus, err := strconv.ParseFloat(value, 64)
+		c.CPU = int(cpus * 1024)
+		return err
+	},
+	"--device": func(c *transform.Container, value string) error {
+		c.Devices = append(c.Devices, transform.ParseDevice(value))
+		return nil
+	},
+	"--dns": func(c *transform.Container, value string) error {
+		c.DNS = append(c.DNS, value)
+		return nil
+	},
+	"--dns-search": func(c *transform.Container, value string) error {
+		c.Domain = append(c.Domain, value)
+		return nil
+	},
+	"--entrypoint": func(c *transform.Container, value string) error {
+		c.Entrypoint = []string{value}
+		return nil
+	},
+	"--env": func(c *transform.Container, value string) error {
+		if c.Environment == nil {
+			c.Environment = map[string]string{}
+		}
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) == 1 {
+			log.Printf("Environment variable %s is passed through from the host, setting it to an empty value", value)
+			parts = append(parts, "")
+		}
+		c.Environment[parts[0]] = parts[1]
+		return nil
+	},
+	"--env-file": func(c *transform.Container, value string) error {
+		c.EnvFile = append(c.EnvFile, value)
+		return nil
+	},
+	"--expose": func(c *transform.Container, value string) error {
+		ports, err := parsePortRange(strings.SplitN(value, "/", 2)[0])
+		c.Expose = append(c.Expose, ports...)
+		return err
+	},
+	"--health-cmd": func(c *transform.Container, value string) error {
+		healthCheck(c).Exec = value
+		return nil
+	},
+	"--health-interval": func(c *transform.Container, value string) (err error) {
+		healthCheck(c).Interval, err = transform.ParseSeconds(value)
+		return err
+	},
+	"--health-retries": func(c *transform.Container, value string) (err error) {
+		healthCheck(c).FailureThreshold, err = strconv.Atoi(value)
+		return err
+	},
+	"--health-start-period": func(c *transform.Container, value string) (err error) {
+		healthCheck(c).StartPeriod, err = transform.ParseSeconds(value)
+		return err
+	},
+	"--health-timeout": func(c *transform.Container, value string) (err error) {
+		healthCheck(c).Timeout, err = transform.ParseSeconds(value)
+		return err
+	},
+	"--hostname": func(c *transform.Container, value string) error {
+		c.Hostname = value
+		return nil
+	},
+	"--label": func(c *transform.Container, value string) error {
+		if c.Labels == nil {
+			c.Labels = map[string]string{}
+		}
+		parts := append(strings.SplitN(value, "=", 2), "")
+		c.Labels[parts[0]] = parts[1]
+		return nil
+	},
+	"--link": func(c *transform.Container, value string) error {
+		c.Links = append(c.Links, value)
+		return nil
+	},
+	"--log-driver": func(c *transform.Container, value string) error {
+		if c.Logging == nil {
+			c.Logging = &transform.Logging{}
+		}
+		c.Logging.Driver = value
+		return nil
+	},
+	"--log-opt": func(c *transform.Container, value string) error {
+		if c.Logging == nil {
+			c.Logging = &transform.Logging{}
+		}
+		if c.Logging.Options == nil {
+			c.Logging.Options = map[string]string{}
+		}
+		parts := append(strings.SplitN(value, "=", 2), "")
+		c.Logging.Options[parts[0]] = parts[1]
+		return nil
+	},
+	"--memory": func(c *transform.Container, value string) (err error) {
+		c.Memory, err = transform.ParseBytes(value)
+		return err
+	},
+	"--name": func(c *transform.Container, value string) error {
+		c.Name = value
+		return nil
+	},
+	"--net": func(c *transform.Container, value string) error {
+		c.NetworkMode = value
+		return nil
+	},
+	"--net-alias": func(c *transform.Container, value string) error {
+		c.Network = append(c.Network, value)
+		return nil
+	},
+	"--pid": func(c *transform.Container, value string) error {
+		c.Pid = value
+		return nil
+	},
+	"--publish": func(c *transform.Container, value string) error {
+		mappings, err := parsePublish(value)
+		if err != nil {
+			return err
+		}
+		if c.PortMappings == nil {
+			c.PortMappings = &transform.PortMappings{}
+		}
+		*c.PortMappings = append(*c.PortMappings, mappings...)
+		return nil
+	},
+	"--pull": func(c *transform.Container, value string) error {
+		c.PullImagePolicy = value
+		return nil
+	},
+	"--restart": func(c *transform.Container, value string) error {
+		c.Restart = value
+		return nil
+	},
+	"--security-opt": func(c *transform.Container, value string) error {
+		c.SecurityOpt = append(c.SecurityOpt, value)
+		return nil
+	},
+	"--shm-size": func(c *transform.Container, value string) (err error) {
+		c.ShmSize, err = transform.ParseBytes(value)
+		return err
+	},
+	"--stop-signal": func(c *transform.Container, value string) error {
+		c.StopSignal = value
+		return nil
+	},
+	"--sysctl": func(c *transform.Container, value string) error {
+		if c.Sysctls == nil {
+			c.Sysctls = map[string]string{}
+		}
+		parts := append(strings.SplitN(value, "=", 2), "")
+		c.Sysctls[parts[0]] = parts[1]
+		return nil
+	},
+	"--tmpfs": func(c *transform.Container, value string) error {
+		tmpfs, err := transform.ParseTmpfs(value)
+		c.Tmpfs = append(c.Tmpfs, tmpfs)
+		return err
+	},
+	"--ulimit": func(c *transform.Container, value string) error {
+		ulimit, err := transform.ParseUlimit(value)
+		if err != nil {
+			return err
+		}
+		c.Ulimits = append(c.Ulimits, ulimit)
+		return nil
+	},
+	"--user": func(c *transform.Container, value string) error {
+		c.User = value
+		return nil
+	},
+	"--volume": func(c *transform.Container, value string) error {
+		if c.Volumes == nil {
+			c.Volumes = &transform.IntermediateVolumes{}
+		}
+		*c.Volumes = append(*c.Volumes, parseVolume(value))
+		return nil
+	},
+	"--volumes-from": func(c *transform.Container, value string) error {
+		c.VolumesFrom = append(c.VolumesFrom, value)
+		return nil
+	},
+	"--workdir": func(c *transform.Container, value string) error {
+		c.WorkDir = value
+		return nil
+	},
+}
+
+// flagAliases are alternate spellings of flags in runFlags
+var flagAliases = map[string]string{
+	"--network":       "--net",
+	"--network-alias": "--net-alias",
+}
+
+// boolFlags are the docker run flags which take no value. Those without an
+// intermediate equivalent are nil.
+var boolFlags = map[string]func(c *transform.Container, value bool){
+	"--init": func(c *transform.Container, value bool) {
+		c.Init = value
+	},
+	"--no-healthcheck": func(c *transform.Container, value bool) {
+		c.NoHealthCheck = value
+	},
+	"--privileged": func(c *transform.Container, value bool) {
+		c.Privileged = value
+	},
+	"--read-only": func(c *transform.Container, value bool) {
+		c.ReadOnly = value
+	},
+	"--detach":                nil,
+	"--disable-content-trust": nil,
+	"--interactive":           nil,
+	"--oom-kill-disable":      nil,
+	"--publish-all":           nil,
+	"--quiet":                 nil,
+	"--rm":                    nil,
+	"--sig-proxy":             nil,
+	"--tty":                   nil,
+}
+
+// ignoredFlags are the docker run flags which take a value but have no
+// intermediate equivalent
+var ignoredFlags = map[string]bool{
+	"--add-host": true, "--annotation": true, "--attach": true,
+	"--blkio-weight": true, "--blkio-weight-device": true, "--cgroup-parent": true,
+	"--cgroupns": true, "--cidfile": true, "--cpu-period": true, "--cpu-quota": true,
+	"--cpu-rt-period": true, "--cpu-rt-runtime": true, "--cpuset-cpus": true,
+	"--cpuset-mems": true, "--detach-keys": true, "--device-cgroup-rule": true,
+	"--device-read-bps": true, "--device-read-iops": true, "--device-write-bps": true,
+	"--device-write-iops": true, "--dns-option": true, "--domainname": true,
+	"--gpus": true, "--group-add": true, "--health-start-interval": true,
+	"--ip": true, "--ip6": true, "--ipc": true, "--isolation": true,
+	"--kernel-memory": true, "--label-file": true, "--link-local-ip": true,
+	"--mac-address": true, "--memory-reservation": true, "--memory-swap": true,
+	"--memory-swappiness": true, "--mount": true, "--oom-score-adj": true,
+	"--pids-limit": true, "--platform": true, "--pod": true, "--runtime": true,
+	"--stop-timeout": true, "--storage-opt": true, "--userns": true, "--uts": true,
+	"--volume-driver": true,
+}
+
+// healthCheck returns the container's health check, which the --health
+// flags build up
+func healthCheck(c *transform.Container) *transform.HealthCheck {
+	if len(c.HealthChecks) == 0 {
+		c.HealthChecks = []*transform.HealthCheck{{}}
+	}
+	return c.HealthChecks[0]
+}
+
+// parsePortRange parses a port or a range of ports such as 8000-8010
+func parsePortRange(value string) ([]int, error) {
+	if len(value) == 0 {
+		return []int{0}, nil
+	}
+	bounds := strings.SplitN(value, "-", 2)
+	start, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %s", value)
+	}
+	end := start
+	if len(bounds) == 2 {
+		end, err = strconv.Atoi(bounds[1])
+		if err != nil || end < start {
+			return nil, fmt.Errorf("invalid port range %s", value)
+		}
+	}
+	ports := []int{}
+	for port := start; port <= end; port++ {
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// parsePublish parses the value of a --publish flag, in the form
+// [[ip:][hostPort]:]containerPort[/protocol], into one mapping per port
+func parsePublish(value string) ([]transform.PortMapping, error) {
+	protocol := "tcp"
+	if idx := strings.LastIndex(value, "/"); idx >= 0 {
+		protocol = value[idx+1:]
+		value = value[:idx]
+	}
+
+	hostIP, hostPorts := "", ""
+	if idx := strings.LastIndex(value, "]:"); strings.HasPrefix(value, "[") && idx >= 0 {
+		hostIP = value[1:idx]
+		value = value[idx+2:]
+	}
+	parts := strings.Split(value, ":")
+	containerPorts := parts[len(parts)-1]
+	if len(parts) > 1 {
+		hostPorts = parts[len(parts)-2]
+	}
+	if len(parts) > 2 {
+		hostIP = strings.Join(parts[:len(parts)-2], ":")
+	}
+
+	containers, err := parsePortRange(containerPorts)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := parsePortRange(hostPorts)
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) != len(containers) && len(hosts) > 1 {
+		return nil, fmt.Errorf("host port range %s does not match container port range %s", hostPorts, containerPorts)
+	}
+
+	mappings := []transform.PortMapping{}
+	for i, port := range containers {
+		mapping := transform.PortMapping{HostIP: hostIP, ContainerPort: port, Protocol: protocol}
+		if len(hosts) > 1 {
+			mapping.HostPort = hosts[i]
+		} else if len(containers) == 1 {
+			mapping.HostPort = hosts[0]
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// parseVolume parses the value of a --volume flag, in the form
+// [host:]container[:options]
+func parseVolume(value string) transform.IntermediateVolume {
+	iv := transform.IntermediateVolume{}
+	parts := strings.Split(value, ":")
+	if len(parts) == 1 {
+		iv.Container = parts[0]
+		return iv
+	}
+	iv.Host = parts[0]
+	iv.Container = parts[1]
+	if len(parts) > 2 {
+		for _, option := range strings.Split(parts[2], ",") {
+			if option == "ro" {
+				iv.ReadOnly = true
+			}
+		}
+	}
+	return iv
+}
+
+// expandFlag rewrites a short flag, or a group of them such as -it or -p80:80,
+// in long form
+func expandFlag(arg string) ([]string, error) {
+	if strings.HasPrefix(arg, "--") {
+		return []string{arg}, nil
+	}
+	response := []string{}
+	for i := 1; i < len(arg); i++ {
+		long, ok := shortFlags[arg[i]]
+		if !ok {
+			return nil, fmt.Errorf("unknown flag -%c in %s", arg[i], arg)
+		}
+		if _, ok := boolFlags[long]; ok {
+			response = append(response, long)
+			continue
+		}
+		if value := strings.TrimPrefix(arg[i+1:], "="); len(value) > 0 {
+			long += "=" + value
+		}
+		return append(response, long), nil
+	}
+	return response, nil
+}
+
+// parseRun parses the arguments following docker run into a container
+func parseRun(args []string) (*transform.Container, error) {
+	ir := &transform.Container{}
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") || args[i] == "-" || args[i] == "--" {
+			if args[i] == "--" {
+				i++
+			}
+			if i >= len(args) {
+				break
+			}
+			ir.Image = args[i]
+			if len(args) > i+1 {
+				ir.Command = args[i+1:]
+			}
+			return ir, nil
+		}
+
+		flags, err := expandFlag(args[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, flag := range flags {
+			parts := strings.SplitN(flag, "=", 2)
+			name := parts[0]
+			if alias, ok := flagAliases[name]; ok {
+				name = alias
+			}
+
+			if apply, ok := boolFlags[name]; ok {
+				value := len(parts) == 1 || parts[1] != "false"
+				if apply != nil {
+					apply(ir, value)
+				}
+				continue
+			}
+
+			apply, known := runFlags[name]
+			if !known && !ignoredFlags[name] {
+				// Whether an unknown flag takes the next argument is unknown,
+				// so only flags written as --flag=value can be skipped
+				if len(parts) == 1 {
+					return nil, fmt.Errorf("unknown flag %s, write it as %s=value to skip it", name, name)
+				}
+				log.Printf("Unknown flag %s, skipping it", name)
+				continue
+			}
+			if len(parts) == 1 {
+				i++
+				if i >= len(args) {
+					return nil, fmt.Errorf("flag %s requires a value", name)
+				}
+				parts = append(parts, args[i])
+			}
+			if !known {
+				log.Printf("Flag %s has no equivalent, skipping it", name)
+				continue
+			}
+			if err := apply(ir, parts[1]); err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %s", name, err)
+			}
+		}
+	}
+	return nil, fmt.Errorf("no image specified")
+}
+
+// runArgs returns the arguments following the run subcommand if a command
+// runs a container
+func runArgs(words []string) ([]string, bool) {
+	for len(words) > 0 && (words[0] == "sudo" || words[0] == "exec" || strings.Contains(words[0], "=")) {
+		words = words[1:]
+	}
+	if len(words) == 0 || !runtimes[path.Base(words[0])] {
+		return nil, false
+	}
+	for i := 1; i < len(words); i++ {
+		switch {
+		case runSubcommands[words[i]]:
+			return words[i+1:], true
+		case words[i] == "container":
+			continue
+		case globalValueFlags[words[i]]:
+			i++
+		case !strings.HasPrefix(words[i], "-"):
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// containerName derives a name for an unnamed container from its image
+func containerName(image string) string {
+	name := path.Base(strings.SplitN(image, "@", 2)[0])
+	return strings.SplitN(name, ":", 2)[0]
+}
+
+// IngestContainers satisfies InputFormat so docker run commands can be ingested
+func (s Script) IngestContainers(input io.ReadCloser) (*transform.PodData, error) {
+
+	body, err := ioutil.ReadAll(input)
+	defer input.Close()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	commands, err := transform.ShellSplitScript(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	outputPod := transform.PodData{}
+	containers := transform.Containers{}
+	names := map[string]bool{}
+
+	for _, command := range commands {
+		args, ok := runArgs(command)
+		if !ok {
+			continue
+		}
+		ir, err := parseRun(args)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %s", strings.Join(command, " "), err)
+		}
+		if len(ir.Name) == 0 {
+			base := containerName(ir.Image)
+			ir.Name = base
+			for i := 2; names[ir.Name]; i++ {
+				ir.Name = base + "-" + strconv.Itoa(i)
+			}
+		}
+		names[ir.Name] = true
+		containers = append(containers, *ir)
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("no docker run commands found")
+	}
+
+	sort.Sort(containers)
+	outputPod.Containers = &containers
+	return &outputPod, nil
+}
@@ -0,0 +1,155 @@
+package script
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// Option is a single docker run flag, followed by its value if it takes one
+type Option []string
+
+// sortedKeys returns a map's keys in order, so options are emitted consistently
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RunOptions returns the docker run options for a container, excluding its
+// image and command
+func RunOptions(c transform.Container) []Option {
+	options := []Option{}
+	for _, capability := range c.CapAdd {
+		options = append(options, Option{"--cap-add", capability})
+	}
+	for _, capability := range c.CapDrop {
+		options = append(options, Option{"--cap-drop", capability})
+	}
+	if c.CPU > 0 {
+		options = append(options, Option{"--cpu-shares=" + strconv.Itoa(c.CPU)})
+	}
+	for _, device := range c.Devices {
+		options = append(options, Option{"--device", device.String()})
+	}
+	for _, dns := range c.DNS {
+		options = append(options, Option{"--dns", dns})
+	}
+	for _, domain := range c.Domain {
+		options = append(options, Option{"--dns-search", domain})
+	}
+	if len(c.Entrypoint) > 0 {
+		options = append(options, Option{"--entrypoint=" + c.Entrypoint[0]})
+	}
+	for _, envFile := range c.EnvFile {
+		options = append(options, Option{"--env-file", envFile})
+	}
+	for _, k := range sortedKeys(c.Environment) {
+		options = append(options, Option{"--env", k + "=" + c.Environment[k]})
+	}
+	for _, port := range c.Expose {
+		options = append(options, Option{"--expose", strconv.Itoa(port)})
+	}
+	if check := c.ExecHealthCheck(); check != nil {
+		options = append(options, Option{"--health-cmd", check.Exec})
+		if check.Interval > 0 {
+			options = append(options, Option{"--health-interval=" + transform.FormatSeconds(check.Interval)})
+		}
+		if check.FailureThreshold > 0 {
+			options = append(options, Option{"--health-retries=" + strconv.Itoa(check.FailureThreshold)})
+		}
+		if check.StartPeriod > 0 {
+			options = append(options, Option{"--health-start-period=" + transform.FormatSeconds(check.StartPeriod)})
+		}
+		if check.Timeout > 0 {
+			options = append(options, Option{"--health-timeout=" + transform.FormatSeconds(check.Timeout)})
+		}
+	}
+	if len(c.Hostname) > 0 {
+		options = append(options, Option{"--hostname=" + c.Hostname})
+	}
+	if c.Init {
+		options = append(options, Option{"--init"})
+	}
+	for _, k := range sortedKeys(c.Labels) {
+		options = append(options, Option{"--label", k + "=" + c.Labels[k]})
+	}
+	for _, link := range c.Links {
+		options = append(options, Option{"--link", link})
+	}
+	if c.Logging != nil {
+		options = append(options, Option{"--log-driver", c.Logging.Driver})
+		for _, k := range sortedKeys(c.Logging.Options) {
+			options = append(options, Option{"--log-opt", k + "=" + c.Logging.Options[k]})
+		}
+	}
+	if c.Memory > 0 {
+		options = append(options, Option{"--memory=" + strconv.Itoa(c.Memory) + "b"})
+	}
+	if len(c.Name) > 0 {
+		options = append(options, Option{"--name", c.Name})
+	}
+	for _, network := range c.Network {
+		options = append(options, Option{"--net-alias", network})
+	}
+	if len(c.NetworkMode) > 0 {
+		options = append(options, Option{"--net", c.NetworkMode})
+	}
+	if c.NoHealthCheck && c.ExecHealthCheck() == nil {
+		options = append(options, Option{"--no-healthcheck"})
+	}
+	if len(c.Pid) > 0 {
+		options = append(options, Option{"--pid", c.Pid})
+	}
+	if c.PortMappings != nil {
+		for _, mapping := range *c.PortMappings {
+			options = append(options, Option{"--publish", stringifyPortMapping(mapping)})
+		}
+	}
+	if c.Privileged {
+		options = append(options, Option{"--privileged"})
+	}
+	if c.ReadOnly {
+		options = append(options, Option{"--read-only"})
+	}
+	if len(c.Restart) > 0 {
+		options = append(options, Option{"--restart", c.Restart})
+	}
+	for _, opt := range c.SecurityOpt {
+		options = append(options, Option{"--security-opt", opt})
+	}
+	if c.ShmSize > 0 {
+		options = append(options, Option{"--shm-size=" + transform.FormatBytes(c.ShmSize)})
+	}
+	if len(c.StopSignal) > 0 {
+		options = append(options, Option{"--stop-signal=" + c.StopSignal})
+	}
+	for _, k := range sortedKeys(c.Sysctls) {
+		options = append(options, Option{"--sysctl", k + "=" + c.Sysctls[k]})
+	}
+	for _, tmpfs := range c.Tmpfs {
+		options = append(options, Option{"--tmpfs", tmpfs.String()})
+	}
+	for _, ulimit := range c.Ulimits {
+		options = append(options, Option{"--ulimit", ulimit.String()})
+	}
+	if len(c.User) > 0 {
+		options = append(options, Option{"--user=" + c.User})
+	}
+	if c.Volumes != nil {
+		for _, volume := range *c.Volumes {
+			options = append(options, Option{"--volume", stringifyVolume(volume)})
+		}
+	}
+	for _, volumesFrom := range c.VolumesFrom {
+		options = append(options, Option{"--volumes-from", volumesFrom})
+	}
+	if len(c.WorkDir) > 0 {
+		options = append(options, Option{"--workdir=" + c.WorkDir})
+	}
+	return options
+}
@@ -40,21 +40,55 @@ func stringifyVolume(volume transform.IntermediateVolume) string {
 	return strings.Trim(strings.Join(volStr, ":"), ":")
 }
 
-// Script represents a list of docker container run commands.
-// It implements OutputFormat
-type Script struct{}
+// Script represents a list of container run commands.
+// It implements InputFormat and OutputFormat
+type Script struct {
+	Engine string // docker, podman, or nerdctl. Defaults to docker
+	Pod    bool   // run the containers in a podman pod
+	Array  bool   // collect each command's arguments in a bash array
+}
+
+// engine returns the command of the script's container engine
+func (s Script) engine() string {
+	if len(s.Engine) == 0 {
+		return "docker"
+	}
+	return s.Engine
+}
 
-// EmitContainers satisfies OutputFormat so ECS tasks can be emitted
+// EmitContainers satisfies OutputFormat so run commands can be emitted
 func (s Script) EmitContainers(input *transform.PodData) ([]byte, error) {
 
+	var buffer bytes.Buffer
+	options := func(c transform.Container) []Option {
+		return EngineOptions(s.engine(), c)
+	}
 	funcMap := template.FuncMap{
-		"stringifyPort":   stringifyPortMapping,
-		"stringifyVolume": stringifyVolume,
+		"engine":      s.engine,
+		"options":     func(c transform.Container) []Option { return options(c) },
+		"quote":       transform.ShellQuote,
+		"quoteArgs":   transform.ShellJoin,
+		"quoteOption": quoteOption,
+		"variable":    variable,
+	}
+	runText, podText := runTemplate, podCreateTemplate
+	if s.Array {
+		runText, podText = runArrayTemplate, podCreateArrayTemplate
 	}
 
-	t := template.Must(template.New("container").Funcs(funcMap).Parse(dockerRunTemplate))
+	if s.Pod {
+		pod, containerOptions := emitPod(input)
+		options = func(c transform.Container) []Option {
+			return containerOptions[c.Name]
+		}
+		t := template.Must(template.New("pod").Funcs(funcMap).Parse(podText))
+		if err := t.Execute(&buffer, pod); err != nil {
+			log.Println("Error executing template:", err)
+		}
+	}
+
+	t := template.Must(template.New("container").Funcs(funcMap).Parse(runText))
 
-	var buffer bytes.Buffer
 	for _, c := range *input.Containers {
 		err := t.Execute(&buffer, c)
 		if err != nil {
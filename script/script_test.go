@@ -4,31 +4,138 @@ import (
 	"bytes"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/transform"
 	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
 )
 
 func TestEmitContainers(t *testing.T) {
-	cf := compose.DockerCompose{}
+	cases := []struct {
+		input   string
+		fixture string
+		script  Script
+	}{
+		{"./test_fixtures/docker-compose.yaml", "./test_fixtures/compose.out", Script{}},
+		{"./test_fixtures/docker-compose.yaml", "./test_fixtures/nerdctl.out", Script{Engine: "nerdctl"}},
+		{"./test_fixtures/docker-compose.yaml", "./test_fixtures/podman-pod.out", Script{Engine: "podman", Pod: true}},
+		{"./test_fixtures/hostile.yaml", "./test_fixtures/hostile.out", Script{}},
+		{"./test_fixtures/hostile.yaml", "./test_fixtures/hostile-bash.out", Script{Array: true}},
+	}
+	for _, c := range cases {
+		f, err := os.Open(c.input)
+		if err != nil {
+			t.Errorf("Failed to open fixture: %s", err)
+		}
+
+		bp, err := compose.DockerCompose{}.IngestContainers(f)
+		if err != nil {
+			t.Errorf("Failed to ingest containers: %s", err)
+		}
+
+		got, err := c.script.EmitContainers(bp)
+		if err != nil {
+			t.Errorf("Failed to emit containers: %s", err)
+		}
+
+		expected, err := ioutil.ReadFile(c.fixture)
+		if err != nil {
+			t.Errorf("Failed to open file: %s", err)
+		}
+
+		if bytes.Compare(got, expected) != 0 {
+			diff := diffmatchpatch.New()
+			diffs := diff.DiffMain(string(expected), string(got), false)
+			t.Errorf("%s differs from output: %s", c.fixture, diff.PatchToText(diff.PatchMake(diffs)))
+		}
+	}
+}
+
+func TestQuotingRoundTrip(t *testing.T) {
+	f, err := os.Open("./test_fixtures/hostile.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := compose.DockerCompose{}.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	script, err := Script{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	got, err := Script{}.IngestContainers(ioutil.NopCloser(bytes.NewReader(script)))
+	if err != nil {
+		t.Errorf("Failed to ingest script: %s", err)
+	}
+
+	// docker run only takes health checks in the shell form
+	expected := (*bp.Containers)[0]
+	expected.HealthChecks[0].ExecForm = false
+	assert.Equal(t, expected, (*got.Containers)[0])
+}
+
+func TestQuote(t *testing.T) {
+	cases := map[string]string{
+		"":            "''",
+		"plain":       "plain",
+		"KEY=a/b:c,d": "KEY=a/b:c,d",
+		"two words":   "'two words'",
+		"it's":        `'it'\''s'`,
+		"$HOME":       "'$HOME'",
+		"~/config":    "'~/config'",
+		"line\nbreak": "'line\nbreak'",
+	}
+	for word, expected := range cases {
+		assert.Equal(t, expected, quoteOption(Option{word}), word)
+	}
+	assert.Equal(t, "my_app_2", variable("my.app-2"))
+}
 
-	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+func TestEmitPod(t *testing.T) {
+	bp := &transform.PodData{
+		Name:        "app",
+		HostNetwork: true,
+		Containers: &transform.Containers{
+			{Name: "db", Image: "postgres", PortMappings: &transform.PortMappings{{HostPort: 5432, ContainerPort: 5432}}},
+			{Name: "web", Image: "nginx", Links: []string{"db"}, NetworkMode: "bridge", Expose: []int{8080}, PortMappings: &transform.PortMappings{{HostPort: 80, ContainerPort: 80}}},
+		},
+	}
+
+	pod, options := emitPod(bp)
+	assert.Equal(t, "app", pod.Name)
+	assert.Equal(t, []Option{{"--network", "host"}}, pod.Options)
+	assert.Equal(t, []Option{{"--name", "web"}, {"--pod", "app"}}, options["web"])
+	for name, containerOptions := range options {
+		for _, option := range containerOptions {
+			assert.NotEqual(t, "--expose", flagName(option), "container %s keeps %s", name, strings.Join(option, " "))
+		}
+	}
+}
+
+func TestIngestContainers(t *testing.T) {
+	f, err := os.Open("./test_fixtures/runbook.sh")
 	if err != nil {
 		t.Errorf("Failed to open fixture: %s", err)
 	}
 
-	bp, err := cf.IngestContainers(f)
+	bp, err := Script{}.IngestContainers(f)
 	if err != nil {
 		t.Errorf("Failed to ingest containers: %s", err)
 	}
 
-	got, err := Script{}.EmitContainers(bp)
+	got, err := compose.DockerCompose{}.EmitContainers(bp)
 	if err != nil {
 		t.Errorf("Failed to emit containers: %s", err)
 	}
 
-	expected, err := ioutil.ReadFile("./test_fixtures/compose.out")
+	expected, err := ioutil.ReadFile("./test_fixtures/runbook.yaml")
 	if err != nil {
 		t.Errorf("Failed to open file: %s", err)
 	}
@@ -39,3 +146,65 @@ func TestEmitContainers(t *testing.T) {
 		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
 	}
 }
+
+func TestSplitCommands(t *testing.T) {
+	got, err := transform.ShellSplitScript("docker run \\\n  -e A='x y' -e \"B=\\\"q\\\"\" \\\n  alpine; echo done # comment\n\necho '' a\\ b")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"docker", "run", "-e", "A=x y", "-e", `B="q"`, "alpine"},
+		{"echo", "done"},
+		{"echo", "", "a b"},
+	}, got)
+
+	_, err = transform.ShellSplitScript("docker run -e 'A=x alpine")
+	assert.NotNil(t, err)
+}
+
+func TestParsePublish(t *testing.T) {
+	cases := map[string][]transform.PortMapping{
+		"80":                {{ContainerPort: 80, Protocol: "tcp"}},
+		"8080:80":           {{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"}},
+		"127.0.0.1::80/udp": {{HostIP: "127.0.0.1", ContainerPort: 80, Protocol: "udp"}},
+		"[::1]:53:53":       {{HostIP: "::1", HostPort: 53, ContainerPort: 53, Protocol: "tcp"}},
+		"8000-8001:80-81": {
+			{HostPort: 8000, ContainerPort: 80, Protocol: "tcp"},
+			{HostPort: 8001, ContainerPort: 81, Protocol: "tcp"},
+		},
+	}
+	for value, expected := range cases {
+		got, err := parsePublish(value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, expected, got, value)
+	}
+
+	_, err := parsePublish("8000-8002:80-81")
+	assert.NotNil(t, err)
+}
+
+func TestParseBytes(t *testing.T) {
+	cases := map[string]int{"67108864b": 67108864, "512m": 536870912, "1G": 1073741824, "64k": 65536, "2mb": 2097152, "100": 100}
+	for value, expected := range cases {
+		got, err := transform.ParseBytes(value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, expected, got, value)
+	}
+
+	_, err := transform.ParseBytes("lots")
+	assert.NotNil(t, err)
+}
+
+func TestNoHealthCheck(t *testing.T) {
+	c, err := parseRun([]string{"--no-healthcheck", "nginx"})
+	assert.Nil(t, err)
+	assert.True(t, c.NoHealthCheck)
+	assert.Contains(t, RunOptions(*c), Option{"--no-healthcheck"})
+}
+
+func TestParseUnknownFlags(t *testing.T) {
+	c, err := parseRun([]string{"--memory-swappiness", "0", "--made-up=1", "nginx"})
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx", c.Image)
+
+	_, err = parseRun([]string{"--made-up", "0", "nginx"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,23 @@
+package script
+
+import "github.com/micahhausler/container-tx/transform"
+
+// quoteOption quotes each argument of a run option
+func quoteOption(option Option) string {
+	return transform.ShellJoin(option)
+}
+
+// variable returns a shell variable name for a container's name
+func variable(name string) string {
+	response := []rune{}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			r = '_'
+		}
+		response = append(response, r)
+	}
+	return string(response)
+}
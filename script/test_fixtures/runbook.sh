@@ -0,0 +1,47 @@
+#!/bin/sh
+# Start the database first
+docker run -d --name db \
+    --env POSTGRES_PASSWORD='s3cr3t pass' \
+    -v pgdata:/var/lib/postgresql/data \
+    --memory 512m \
+    --shm-size 256m \
+    --sysctl net.core.somaxconn=1024 \
+    --ulimit nofile=1024:2048 \
+    postgres:9.6
+
+sudo docker run -it --rm --init --read-only \
+    --cap-add NET_ADMIN \
+    --cap-drop=ALL \
+    --cpu-shares=200 \
+    --device /dev/fuse \
+    --dns 8.8.8.8 \
+    --dns-search cluster.local \
+    --entrypoint=/bin/myapp \
+    -e PGHOST=db \
+    --env=PGUSER=postgres \
+    --expose 8080 \
+    -h webserver \
+    --label "com.example.description=Accounting webapp" \
+    --link db \
+    --log-driver gelf \
+    --log-opt gelf-address=udp://127.0.0.1:12900 \
+    --memory=67108864b \
+    --name web \
+    --network-alias some-network \
+    --net bridge \
+    --pid host \
+    -p 127.0.0.1:5000:5000 \
+    -p5001:5001 \
+    --publish 53:53/udp \
+    --privileged \
+    --restart always \
+    --security-opt no-new-privileges \
+    --stop-signal=SIGTERM \
+    --tmpfs /run:size=64m,noexec \
+    --user=root \
+    --volume /etc/ssl:/etc/ssl:ro \
+    --volumes-from db \
+    -w /code \
+    me/myapp:1.0 -port 8080 --greeting "hello world" # trailing comment
+
+docker run -d redis && docker run -d redis
@@ -0,0 +1,2 @@
+// Package systemd is for emitting docker containers as systemd service units
+package systemd
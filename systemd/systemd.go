@@ -0,0 +1,117 @@
+package systemd
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/micahhausler/container-tx/script"
+	"github.com/micahhausler/container-tx/transform"
+)
+
+// specifierEscaper escapes systemd's specifier and environment variable expansion
+var specifierEscaper = strings.NewReplacer("%", "%%", "$", "$$")
+
+// quoteEscaper escapes characters within a double quoted systemd argument
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+// Escape quotes a single argument for an Exec line in a systemd unit
+func Escape(arg string) string {
+	arg = specifierEscaper.Replace(arg)
+	if len(arg) == 0 || strings.ContainsAny(arg, " \t\n\"'\\;") {
+		return `"` + quoteEscaper.Replace(arg) + `"`
+	}
+	return arg
+}
+
+// EscapeArgs quotes and joins arguments for an Exec line in a systemd unit
+func EscapeArgs(args []string) string {
+	escaped := []string{}
+	for _, arg := range args {
+		escaped = append(escaped, Escape(arg))
+	}
+	return strings.Join(escaped, " ")
+}
+
+// EscapeOption quotes each argument of a docker run option
+func EscapeOption(option script.Option) string {
+	return EscapeArgs(option)
+}
+
+// RestartPolicy converts a docker restart policy into the value of a systemd
+// unit's Restart setting. Units restart always unless told otherwise
+func RestartPolicy(policy string) string {
+	switch strings.SplitN(policy, ":", 2)[0] {
+	case "no":
+		return "no"
+	case "on-failure":
+		return "on-failure"
+	}
+	return "always"
+}
+
+// runOptions returns a container's docker run options, leaving restarts to systemd
+func runOptions(c transform.Container) []script.Option {
+	options := []script.Option{}
+	for _, option := range script.RunOptions(c) {
+		if option[0] != "--restart" {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// unitName returns the name of a container's service unit
+func unitName(name string) string {
+	return name + ".service"
+}
+
+// Unit represents a set of systemd service units, one per container.
+// It implements OutputFormat and FileOutputFormat
+type Unit struct{}
+
+// EmitFiles satisfies FileOutputFormat so each systemd unit can be written to its own file
+func (u Unit) EmitFiles(input *transform.PodData) (map[string][]byte, error) {
+
+	funcMap := template.FuncMap{
+		"escape":       Escape,
+		"escapeArgs":   EscapeArgs,
+		"escapeOption": EscapeOption,
+		"options":      runOptions,
+		"restart":      RestartPolicy,
+		"unitName":     unitName,
+	}
+
+	t := template.Must(template.New("unit").Funcs(funcMap).Parse(unitTemplate))
+
+	files := map[string][]byte{}
+	for _, c := range *input.Containers {
+		var buffer bytes.Buffer
+		err := t.Execute(&buffer, c)
+		if err != nil {
+			return nil, err
+		}
+		files[unitName(c.Name)] = buffer.Bytes()
+	}
+
+	return files, nil
+}
+
+// EmitContainers satisfies OutputFormat so systemd units can be emitted
+func (u Unit) EmitContainers(input *transform.PodData) ([]byte, error) {
+	files, err := u.EmitFiles(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	for i, c := range *input.Containers {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString("######## " + unitName(c.Name) + " ########\n")
+		buffer.Write(files[unitName(c.Name)])
+	}
+
+	return buffer.Bytes(), nil
+}
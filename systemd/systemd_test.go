@@ -0,0 +1,72 @@
+package systemd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/micahhausler/container-tx/compose"
+	"github.com/micahhausler/container-tx/transform"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, "alpine", Escape("alpine"))
+	assert.Equal(t, `""`, Escape(""))
+	assert.Equal(t, `"com.example.description=Accounting webapp"`, Escape("com.example.description=Accounting webapp"))
+	assert.Equal(t, `"say \"hi\""`, Escape(`say "hi"`))
+	assert.Equal(t, "100%%", Escape("100%"))
+	assert.Equal(t, "$$HOME", Escape("$HOME"))
+}
+
+func TestEmitContainers(t *testing.T) {
+	cf := compose.DockerCompose{}
+
+	f, err := os.Open("./test_fixtures/docker-compose.yaml")
+	if err != nil {
+		t.Errorf("Failed to open fixture: %s", err)
+	}
+
+	bp, err := cf.IngestContainers(f)
+	if err != nil {
+		t.Errorf("Failed to ingest containers: %s", err)
+	}
+
+	got, err := Unit{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./test_fixtures/compose.out")
+	if err != nil {
+		t.Errorf("Failed to open file: %s", err)
+	}
+
+	if bytes.Compare(got, expected) != 0 {
+		diff := diffmatchpatch.New()
+		diffs := diff.DiffMain(string(expected), string(got), false)
+		t.Errorf("Input differs from output: %s", diff.PatchToText(diff.PatchMake(diffs)))
+	}
+}
+
+func TestEmitStopSignal(t *testing.T) {
+	bp := &transform.PodData{
+		Containers: &transform.Containers{{
+			Name:       "nginx",
+			Image:      "nginx",
+			Links:      []string{"app:backend"},
+			StopSignal: "SIGQUIT",
+		}},
+	}
+
+	got, err := Unit{}.EmitContainers(bp)
+	if err != nil {
+		t.Errorf("Failed to emit containers: %s", err)
+	}
+
+	assert.Contains(t, string(got), "Requires=docker.service app.service\n")
+	assert.Contains(t, string(got), "--stop-signal=SIGQUIT")
+	assert.Contains(t, string(got), "ExecStop=/usr/bin/docker kill --signal=SIGQUIT nginx\n")
+}
@@ -0,0 +1,25 @@
+package systemd
+
+const unitTemplate = `[Unit]
+Description={{ .Name }} container
+After=docker.service{{ range .Dependencies }} {{ unitName . }}{{ end }}
+Requires=docker.service{{ range .Dependencies }} {{ unitName . }}{{ end }}
+
+[Service]
+Restart={{ restart .Restart }}
+ExecStartPre=-/usr/bin/docker stop {{ escape .Name }}
+ExecStartPre=-/usr/bin/docker rm {{ escape .Name }}
+{{ if .Image }}ExecStartPre=/usr/bin/docker pull {{ escape .Image }}
+{{ end -}}
+ExecStart=/usr/bin/docker run \
+{{ range options . }}    {{ escapeOption . }} \
+{{ end }}    {{ escape .Image }} {{- with .RunArgs }} \
+        {{ escapeArgs . }}
+{{- end }}
+{{ if .StopSignal }}ExecStop=/usr/bin/docker kill --signal={{ escape .StopSignal }} {{ escape .Name }}
+{{- else }}ExecStop=/usr/bin/docker stop {{ escape .Name }}
+{{- end }}
+
+[Install]
+WantedBy=multi-user.target
+`
@@ -36,6 +36,18 @@ type IntermediateVolume struct {
 	ReadOnly     bool
 }
 
+// NamedVolume returns the name of the volume if it refers to a named volume
+// rather than a host path
+func (iv IntermediateVolume) NamedVolume() string {
+	if len(iv.Host) == 0 {
+		return iv.SourceVolume
+	}
+	if strings.HasPrefix(iv.Host, "/") || strings.HasPrefix(iv.Host, ".") || strings.HasPrefix(iv.Host, "~") {
+		return ""
+	}
+	return iv.Host
+}
+
 // IntermediateVolumes is a composite type for slices of IntermediateVolume
 type IntermediateVolumes []IntermediateVolume
 
@@ -45,6 +57,27 @@ func (iv IntermediateVolumes) Less(i, j int) bool {
 	return strings.Compare(iv[i].Container, iv[j].Container) < 0
 }
 
+// Device is an intermediate representation for a host device added to a container
+type Device struct {
+	Host        string
+	Container   string
+	Permissions string // some of r, w, and m, or all of them if empty
+}
+
+// Tmpfs is an intermediate representation for a tmpfs mount
+type Tmpfs struct {
+	Container string
+	Size      int // in bytes, or unlimited if 0
+	Options   []string
+}
+
+// Ulimit is an intermediate representation for a resource limit, such as nofile
+type Ulimit struct {
+	Name string
+	Soft int
+	Hard int
+}
+
 // Fetch is an intermediate representation for fetching information
 type Fetch struct {
 	URI string
@@ -52,7 +85,8 @@ type Fetch struct {
 
 // HealthCheck is an intermediate representation for health check information
 type HealthCheck struct {
-	Exec string
+	Exec     string
+	ExecForm bool // Exec is a list of arguments, joined with ShellJoin, run without a shell
 
 	HTTPPath string
 	Port     int
@@ -60,11 +94,29 @@ type HealthCheck struct {
 	Scheme   string
 	Headers  map[string]string
 
-	Interval         int
-	Timeout          int
+	Interval         int // in seconds
+	Timeout          int // in seconds
+	StartPeriod      int // in seconds
 	FailureThreshold int
 }
 
+// Secret is an intermediate representation for a secret a container reads
+type Secret struct {
+	Source string // name of the pod's secret
+	Target string // file name in /run/secrets, or environment variable name
+	UID    string
+	GID    string
+	Mode   int // file permissions, such as 0440
+}
+
+// SecretSource is an intermediate representation for where a pod's secret comes from
+type SecretSource struct {
+	File      string // path of a file holding the secret
+	External  bool   // created outside of the pod, such as with docker secret create
+	Name      string // name of an external secret, if it differs
+	ValueFrom string // ARN of an SSM parameter or Secrets Manager secret
+}
+
 // BuildContext is an intermediary representation for build information
 type BuildContext struct {
 	Context    string
@@ -75,11 +127,14 @@ type BuildContext struct {
 // Container represents the intermediate format in between input and output formats
 type Container struct {
 	Build           *BuildContext
-	Command         string
+	CapAdd          []string
+	CapDrop         []string
+	Command         []string
 	CPU             int // out of 1024
+	Devices         []Device
 	DNS             []string
 	Domain          []string
-	Entrypoint      string
+	Entrypoint      []string
 	EnvFile         []string
 	Environment     map[string]string
 	Essential       bool
@@ -88,6 +143,7 @@ type Container struct {
 	HealthChecks    []*HealthCheck // TODO make a struct
 	Hostname        string
 	Image           string
+	Init            bool
 	Labels          map[string]string
 	Links           []string
 	Logging         *Logging
@@ -95,18 +151,72 @@ type Container struct {
 	Name            string
 	Network         []string
 	NetworkMode     string
+	NoHealthCheck   bool // disables the image's health check
 	Pid             string
 	PortMappings    *PortMappings
 	Privileged      bool
 	PullImagePolicy string
+	ReadOnly        bool
 	Replicas        int
+	Restart         string // no, always, unless-stopped, or on-failure[:max-retries]
+	Schedule        string // ISO 8601 repeating interval, for scheduled jobs
+	Secrets         []Secret
+	SecurityOpt     []string
+	ShellForm       bool // Command is a single command line for /bin/sh -c
+	ShmSize         int  // in bytes
 	StopSignal      string
+	Sysctls         map[string]string
+	Tmpfs           []Tmpfs
+	Ulimits         []Ulimit
 	User            string
 	Volumes         *IntermediateVolumes
 	VolumesFrom     []string // todo make a struct
 	WorkDir         string
 }
 
+// Dependencies returns the names of the containers this container links to or
+// mounts volumes from, which must be started before it
+func (c Container) Dependencies() []string {
+	response := []string{}
+	seen := map[string]bool{}
+	for _, ref := range append(append([]string{}, c.Links...), c.VolumesFrom...) {
+		name := strings.SplitN(ref, ":", 2)[0]
+		if !seen[name] {
+			seen[name] = true
+			response = append(response, name)
+		}
+	}
+	return response
+}
+
+// CommandArgs returns the container's command as arguments, running a shell
+// form command with /bin/sh -c
+func (c Container) CommandArgs() []string {
+	if c.ShellForm && len(c.Command) > 0 {
+		return []string{"/bin/sh", "-c", strings.Join(c.Command, " ")}
+	}
+	return c.Command
+}
+
+// CommandLine returns the container's command as a shell command line
+func (c Container) CommandLine() string {
+	if c.ShellForm {
+		return strings.Join(c.Command, " ")
+	}
+	return ShellJoin(c.Command)
+}
+
+// RunArgs returns the arguments following the image in docker run. docker
+// run's --entrypoint takes a single executable, so the rest of a longer
+// entrypoint precedes the command
+func (c Container) RunArgs() []string {
+	args := []string{}
+	if len(c.Entrypoint) > 1 {
+		args = append(args, c.Entrypoint[1:]...)
+	}
+	return append(args, c.CommandArgs()...)
+}
+
 // Containers is for storing and sorting slices of Container
 type Containers []Container
 
@@ -120,10 +230,12 @@ func (cs Containers) Less(i, j int) bool {
 type PodData struct {
 	Name         string
 	Containers   *Containers
+	RegistryAuth string // location of private registry credentials, such as s3://bucket/key
 	GlobalLabels map[string]string
 	HostNetwork  bool
 	HostPID      bool
 	Replicas     int
+	Secrets      map[string]*SecretSource // keyed by secret name
 }
 
 // InputFormat is an interface for other container formats to ingest containers
@@ -135,3 +247,9 @@ type InputFormat interface {
 type OutputFormat interface {
 	EmitContainers(input *PodData) ([]byte, error)
 }
+
+// FileOutputFormat is an interface for container formats that emit a set of
+// files, keyed by their path relative to the output directory
+type FileOutputFormat interface {
+	EmitFiles(input *PodData) (map[string][]byte, error)
+}
@@ -0,0 +1,64 @@
+package transform
+
+import (
+	"strings"
+	"time"
+)
+
+// HealthCheckExec converts a docker health check test, in its CMD or
+// CMD-SHELL form, into a command line and whether it runs without a shell.
+// Disabled checks return an empty string
+func HealthCheckExec(test []string) (string, bool) {
+	if len(test) < 2 {
+		return "", false
+	}
+	switch strings.ToUpper(test[0]) {
+	case "CMD":
+		return ShellJoin(test[1:]), true
+	case "CMD-SHELL":
+		return strings.Join(test[1:], " "), false
+	}
+	return "", false
+}
+
+// HealthCheckTest converts a health check's command into a docker health
+// check test, in the CMD form if it runs without a shell
+func HealthCheckTest(check *HealthCheck) []string {
+	if check.ExecForm {
+		if args, err := ShellSplit(check.Exec); err == nil {
+			return append([]string{"CMD"}, args...)
+		}
+	}
+	return []string{"CMD-SHELL", check.Exec}
+}
+
+// ParseSeconds parses a duration such as 1m30s into whole seconds
+func ParseSeconds(duration string) (int, error) {
+	if len(duration) == 0 {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, err
+	}
+	return int(d / time.Second), nil
+}
+
+// FormatSeconds formats seconds as a duration such as 1m30s
+func FormatSeconds(seconds int) string {
+	if seconds == 0 {
+		return ""
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// ExecHealthCheck returns the container's first command health check, the
+// only kind docker runs
+func (c Container) ExecHealthCheck() *HealthCheck {
+	for _, check := range c.HealthChecks {
+		if check != nil && len(check.Exec) > 0 {
+			return check
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package transform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseBytes parses a docker size such as 512m or 67108864b
+func ParseBytes(value string) (int, error) {
+	units := map[byte]int{'b': 1, 'k': 1 << 10, 'm': 1 << 20, 'g': 1 << 30}
+	size := strings.TrimSuffix(strings.ToLower(value), "b")
+	multiplier := 1
+	if len(size) > 0 {
+		if unit, ok := units[size[len(size)-1]]; ok {
+			multiplier = unit
+			size = size[:len(size)-1]
+		}
+	}
+	bytes, err := strconv.Atoi(size)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %s", value)
+	}
+	return bytes * multiplier, nil
+}
+
+// FormatBytes formats a size in bytes as megabytes if it is a whole number of them
+func FormatBytes(size int) string {
+	if size%(1<<20) == 0 {
+		return strconv.Itoa(size>>20) + "M"
+	}
+	return strconv.Itoa(size) + "b"
+}
+
+// ParseDevice parses a device in the form host[:container[:permissions]]
+func ParseDevice(value string) Device {
+	parts := strings.SplitN(value, ":", 3)
+	device := Device{Host: parts[0], Container: parts[0]}
+	if len(parts) > 1 {
+		device.Container = parts[1]
+	}
+	if len(parts) > 2 {
+		device.Permissions = parts[2]
+	}
+	return device
+}
+
+// String formats a device in the form host[:container[:permissions]]
+func (d Device) String() string {
+	if d.Container == d.Host && len(d.Permissions) == 0 {
+		return d.Host
+	}
+	response := d.Host + ":" + d.Container
+	if len(d.Permissions) > 0 {
+		response += ":" + d.Permissions
+	}
+	return response
+}
+
+// ParseTmpfs parses a tmpfs mount in the form path[:options], such as
+// /run:size=64m,noexec
+func ParseTmpfs(value string) (Tmpfs, error) {
+	parts := strings.SplitN(value, ":", 2)
+	tmpfs := Tmpfs{Container: parts[0]}
+	if len(parts) == 1 {
+		return tmpfs, nil
+	}
+	for _, option := range strings.Split(parts[1], ",") {
+		if strings.HasPrefix(option, "size=") {
+			size, err := ParseBytes(strings.TrimPrefix(option, "size="))
+			if err != nil {
+				return tmpfs, err
+			}
+			tmpfs.Size = size
+		} else if len(option) > 0 {
+			tmpfs.Options = append(tmpfs.Options, option)
+		}
+	}
+	return tmpfs, nil
+}
+
+// String formats a tmpfs mount in the form path[:options]
+func (t Tmpfs) String() string {
+	options := []string{}
+	if t.Size > 0 {
+		options = append(options, "size="+FormatBytes(t.Size))
+	}
+	options = append(options, t.Options...)
+	if len(options) == 0 {
+		return t.Container
+	}
+	return t.Container + ":" + strings.Join(options, ",")
+}
+
+// ParseUlimit parses a resource limit in the form name=soft[:hard], such as
+// nofile=1024:2048
+func ParseUlimit(value string) (Ulimit, error) {
+	parts := strings.SplitN(value, "=", 2)
+	ulimit := Ulimit{Name: parts[0]}
+	if len(parts) == 1 || len(parts[0]) == 0 {
+		return ulimit, fmt.Errorf("invalid ulimit %s", value)
+	}
+	limits := strings.SplitN(parts[1], ":", 2)
+	soft, err := strconv.Atoi(limits[0])
+	if err != nil {
+		return ulimit, fmt.Errorf("invalid ulimit %s", value)
+	}
+	ulimit.Soft, ulimit.Hard = soft, soft
+	if len(limits) > 1 {
+		if ulimit.Hard, err = strconv.Atoi(limits[1]); err != nil {
+			return ulimit, fmt.Errorf("invalid ulimit %s", value)
+		}
+	}
+	return ulimit, nil
+}
+
+// String formats a resource limit in the form name=soft[:hard]
+func (u Ulimit) String() string {
+	if u.Soft == u.Hard {
+		return u.Name + "=" + strconv.Itoa(u.Soft)
+	}
+	return u.Name + "=" + strconv.Itoa(u.Soft) + ":" + strconv.Itoa(u.Hard)
+}
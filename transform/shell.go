@@ -0,0 +1,142 @@
+package transform
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// shellSafe are the characters a word may contain and be left unquoted
+const shellSafe = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=+@%:,./"
+
+// ShellQuote quotes a word for a POSIX shell if it contains special characters
+func ShellQuote(word string) string {
+	if len(word) > 0 && strings.IndexFunc(word, func(r rune) bool {
+		return !strings.ContainsRune(shellSafe, r)
+	}) < 0 {
+		return word
+	}
+	return "'" + strings.Replace(word, "'", `'\''`, -1) + "'"
+}
+
+// ShellJoin quotes and joins words into a shell command line
+func ShellJoin(words []string) string {
+	quoted := []string{}
+	for _, word := range words {
+		quoted = append(quoted, ShellQuote(word))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// commandSeparators end a shell command when they appear unquoted
+var commandSeparators = []string{"&&", "||", ";", "|", "&"}
+
+// ShellSplitScript splits a shell script into commands, each a list of words.
+// It follows the quoting rules of a POSIX shell: backslash escapes, single
+// and double quotes, line continuations, and comments. Variables and command
+// substitutions are left unexpanded.
+func ShellSplitScript(script string) ([][]string, error) {
+	return tokenize(script, true)
+}
+
+// ShellSplit splits a single command line, such as a container's command,
+// into words. Unlike ShellSplitScript, separators and comments are literal text
+func ShellSplit(line string) ([]string, error) {
+	commands, err := tokenize(line, false)
+	if err != nil || len(commands) == 0 {
+		return nil, err
+	}
+	return commands[0], nil
+}
+
+// tokenize splits a script into words, and into commands if commands is set
+func tokenize(script string, commands bool) ([][]string, error) {
+	response := [][]string{}
+	words := []string{}
+	var word bytes.Buffer
+	inWord := false
+
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			response = append(response, words)
+			words = []string{}
+		}
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(script) {
+				i++
+				if script[i] != '\n' {
+					word.WriteByte(script[i])
+					inWord = true
+				}
+			}
+		case c == '\'':
+			end := strings.IndexByte(script[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(script[i+1 : i+1+end])
+			inWord = true
+			i += end + 1
+		case c == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(script); i++ {
+				if script[i] == '"' {
+					closed = true
+					break
+				}
+				if script[i] == '\\' && i+1 < len(script) {
+					switch script[i+1] {
+					case '\n':
+						i++
+						continue
+					case '"', '\\', '$', '`':
+						i++
+					}
+				}
+				word.WriteByte(script[i])
+			}
+			if !closed {
+				return nil, errors.New("unterminated double quote")
+			}
+		case c == '#' && !inWord && commands:
+			for i+1 < len(script) && script[i+1] != '\n' {
+				i++
+			}
+		case c == '\n' && commands:
+			endCommand()
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			endWord()
+		default:
+			separator := ""
+			for _, sep := range commandSeparators {
+				if commands && strings.HasPrefix(script[i:], sep) {
+					separator = sep
+					break
+				}
+			}
+			if len(separator) > 0 {
+				endCommand()
+				i += len(separator) - 1
+				continue
+			}
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endCommand()
+	return response, nil
+}